@@ -0,0 +1,156 @@
+package buchi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/nfa"
+)
+
+// accepts the infinite words with a run that visits accepting states infinitely often.
+// the JSON is the one of nfa.Nfa, with the final states as accepting states.
+type Buchi interface {
+	Alphabet() nfa.Alphabet
+	SetAlphabet(nfa.Alphabet)
+
+	InitialStates() nfa.StateSet
+	SetInitialStates(nfa.StateSet)
+
+	AcceptingStates() nfa.StateSet
+	SetAcceptingStates(nfa.StateSet)
+
+	States() nfa.StateSet
+	SetStates(nfa.StateSet)
+
+	Transition(nfa.State, nfa.Letter) nfa.StateSet
+	SetTransition(nfa.State, nfa.Letter, nfa.StateSet)
+
+	// whether prefix.loop.loop.loop... is accepted
+	// false if loop is empty or a letter is not in the alphabet
+	AcceptsLasso(prefix, loop []nfa.Letter) bool
+
+	// the same automaton read as Nfa, with the accepting states as final states
+	Nfa() nfa.Nfa
+
+	// see nfa.Nfa.Validate
+	Validate() error
+
+	Copy() Buchi
+
+	String() string
+	json.Marshaler
+	json.Unmarshaler
+}
+
+func NewBuchi() Buchi {
+	return &simpleBuchi{nfa.NewNfa()}
+}
+
+// reads A as Büchi automaton, with the final states as accepting states.
+// fails if A has epsilon transitions.
+func FromNfa(A nfa.Nfa) (Buchi, error) {
+	if nfa.HasEpsilonTransitions(A) == true {
+		return nil, errors.New("Büchi automata have no epsilon transitions")
+	}
+	return &simpleBuchi{A.Copy()}, nil
+}
+
+/*****************************************************************************/
+
+type simpleBuchi struct {
+	automaton nfa.Nfa
+}
+
+func (A simpleBuchi) Alphabet() nfa.Alphabet {
+	return A.automaton.Alphabet()
+}
+
+func (A *simpleBuchi) SetAlphabet(sigma nfa.Alphabet) {
+	A.automaton.SetAlphabet(sigma)
+}
+
+func (A simpleBuchi) InitialStates() nfa.StateSet {
+	return A.automaton.InitialStates()
+}
+
+func (A *simpleBuchi) SetInitialStates(S nfa.StateSet) {
+	A.automaton.SetInitialStates(S)
+}
+
+func (A simpleBuchi) AcceptingStates() nfa.StateSet {
+	return A.automaton.FinalStates()
+}
+
+func (A *simpleBuchi) SetAcceptingStates(F nfa.StateSet) {
+	A.automaton.SetFinalStates(F)
+}
+
+func (A simpleBuchi) States() nfa.StateSet {
+	return A.automaton.States()
+}
+
+func (A *simpleBuchi) SetStates(S nfa.StateSet) {
+	A.automaton.SetStates(S)
+}
+
+func (A simpleBuchi) Transition(q nfa.State, a nfa.Letter) nfa.StateSet {
+	return A.automaton.Transition(q, a)
+}
+
+func (A *simpleBuchi) SetTransition(q nfa.State, a nfa.Letter, S nfa.StateSet) {
+	A.automaton.SetTransition(q, a, S)
+}
+
+// see acceptsLasso
+func (A simpleBuchi) AcceptsLasso(prefix, loop []nfa.Letter) bool {
+	return acceptsLasso(A.automaton, []nfa.StateSet{A.AcceptingStates()}, prefix, loop)
+}
+
+func (A simpleBuchi) Nfa() nfa.Nfa {
+	return A.automaton.Copy()
+}
+
+func (A simpleBuchi) Validate() error {
+	return A.automaton.Validate()
+}
+
+func (A simpleBuchi) Copy() Buchi {
+	return &simpleBuchi{A.automaton.Copy()}
+}
+
+func (A simpleBuchi) String() string {
+	ret := ""
+	ret += fmt.Sprintln("states:", A.States())
+	ret += fmt.Sprintln("alphabet:", A.Alphabet())
+	ret += fmt.Sprintln("initial states:", A.InitialStates())
+	ret += fmt.Sprintln("accepting states:", A.AcceptingStates())
+	ret += fmt.Sprintln("transitions:")
+	for i := 0; i < A.States().Size(); i += 1 {
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
+			s, _ := A.States().At(i)
+			a, _ := A.Alphabet().At(j)
+			if S := A.Transition(s.(nfa.State), a.(nfa.Letter)); S.Size() > 0 {
+				ret += fmt.Sprintln(" ", s, "--", a, "-->", S)
+			}
+		}
+	}
+
+	return ret
+}
+
+func (A simpleBuchi) MarshalJSON() ([]byte, error) {
+	return json.Marshal(A.automaton)
+}
+
+// A is only changed if b is valid and has no epsilon transitions
+func (A *simpleBuchi) UnmarshalJSON(b []byte) error {
+	B := nfa.NewNfa()
+	if err := json.Unmarshal(b, &B); err != nil {
+		return err
+	}
+	if nfa.HasEpsilonTransitions(B) == true {
+		return errors.New("Büchi automata have no epsilon transitions")
+	}
+	A.automaton = B
+	return nil
+}
@@ -0,0 +1,120 @@
+package buchi
+
+import (
+	"encoding/json"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"github.com/hydroo/gomochex/basic/set"
+	"testing"
+)
+
+// infinitely many a
+var infinitelyManyA = []byte(`{"States":["0","1"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"],"b":["0"]},"1":{"a":["1"],"b":["0"]}},"FinalStates":["1"]}`)
+
+// finitely many a, nondeterministic
+var finitelyManyA = []byte(`{"States":["0","1"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["0"],"b":["0","1"]},"1":{"b":["1"]}},"FinalStates":["1"]}`)
+
+func TestAcceptsLasso(t *testing.T) {
+	A := NewBuchi()
+	json.Unmarshal(infinitelyManyA, &A)
+	B := NewBuchi()
+	json.Unmarshal(finitelyManyA, &B)
+
+	type test struct {
+		prefix, loop []nfa.Letter
+		a, b         bool
+	}
+
+	tests := []test{
+		test{[]nfa.Letter{}, []nfa.Letter{"a"}, true, false},
+		test{[]nfa.Letter{"a"}, []nfa.Letter{"b"}, false, true},
+		test{[]nfa.Letter{"a", "a", "a"}, []nfa.Letter{"b", "b"}, false, true},
+		test{[]nfa.Letter{"b", "b"}, []nfa.Letter{"a", "b"}, true, false},
+		test{[]nfa.Letter{}, []nfa.Letter{"b", "b", "b", "a"}, true, false},
+		test{nil, []nfa.Letter{"b"}, false, true},
+		// no infinite word
+		test{[]nfa.Letter{"a"}, []nfa.Letter{}, false, false},
+		// letter not in the alphabet
+		test{[]nfa.Letter{"c"}, []nfa.Letter{"a"}, false, false},
+		test{[]nfa.Letter{}, []nfa.Letter{"b", "c"}, false, false},
+	}
+
+	for k, x := range tests {
+		if A.AcceptsLasso(x.prefix, x.loop) != x.a {
+			t.Error("case", k, "infinitely many a")
+		}
+		if B.AcceptsLasso(x.prefix, x.loop) != x.b {
+			t.Error("case", k, "finitely many a")
+		}
+	}
+
+	// the accepting state must be on the cycle, not only on the stem
+	C := NewBuchi()
+	json.Unmarshal([]byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"1":{"a":["1"]}},"FinalStates":["0"]}`), &C)
+	if C.AcceptsLasso(nil, []nfa.Letter{"a"}) != false {
+		t.Error(C)
+	}
+	C.AcceptingStates().Add(nfa.State("1"))
+	if C.AcceptsLasso(nil, []nfa.Letter{"a"}) != true {
+		t.Error(C)
+	}
+}
+
+func TestBuchiJson(t *testing.T) {
+	A := NewBuchi()
+	if err := json.Unmarshal(infinitelyManyA, &A); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := json.Marshal(A)
+	if err != nil || string(s) != string(infinitelyManyA) {
+		t.Error(err, string(s))
+	}
+
+	if A.AcceptingStates().IsEqual(set.NewSet(nfa.State("1"))) != true || A.Validate() != nil {
+		t.Error(A)
+	}
+
+	// an nfa and a Büchi automaton share their JSON
+	B := nfa.NewNfa()
+	json.Unmarshal(s, &B)
+	if B.IsEqual(A.Nfa()) != true {
+		t.Error(B)
+	}
+
+	C := NewBuchi()
+	if err := json.Unmarshal([]byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{},"EpsilonTransitions":{"0":["1"]},"FinalStates":["1"]}`), &C); err == nil {
+		t.Error(C)
+	}
+	if err := json.Unmarshal([]byte(`{"States":["0"],"Alphabet":["a"],"InitialStates":["1"],"Transitions":{},"FinalStates":["1"]}`), &C); err == nil {
+		t.Error(C)
+	}
+	if C.States().Size() != 0 {
+		t.Error(C)
+	}
+}
+
+func TestFromNfa(t *testing.T) {
+	// a.b*, as Büchi automaton a.b^ω
+	A := nfa.Concat(nfa.OneLetter("a"), nfa.KleeneStar(nfa.OneLetter("b"))).EliminateEpsilons()
+
+	if _, err := FromNfa(A); err != nil {
+		t.Fatal(err)
+	}
+	B, _ := FromNfa(A)
+	if B.AcceptsLasso([]nfa.Letter{"a"}, []nfa.Letter{"b"}) != true || B.AcceptsLasso(nil, []nfa.Letter{"a", "b"}) != false {
+		t.Error(B)
+	}
+
+	// B is a copy
+	B.States().Add(nfa.State("x"))
+	if A.States().Probe(nfa.State("x")) == true {
+		t.Error(A)
+	}
+
+	C := nfa.NewNfa()
+	C.States().Add(nfa.State("0"), nfa.State("1"))
+	C.SetEpsilonTransition("0", set.NewSet(nfa.State("1")))
+	if _, err := FromNfa(C); err == nil {
+		t.Error(C)
+	}
+}
@@ -0,0 +1,296 @@
+package buchi
+
+import (
+	"github.com/hydroo/gomochex/automaton/nfa"
+)
+
+// an accepting run Stem.Cycle.Cycle... on the word StemWord.CycleWord.CycleWord...
+// StemWord[i] leads from Stem[i] to the next state of the run, CycleWord[i] from Cycle[i] to the
+// next state of the cycle, the last letter back to Cycle[0]. hence len(Stem) == len(StemWord) and
+// len(Cycle) == len(CycleWord) > 0. the run starts in Stem[0], or in Cycle[0] if the stem is empty.
+type Lasso struct {
+	Stem, Cycle         []nfa.State
+	StemWord, CycleWord []nfa.Letter
+}
+
+// nested depth first search (Courcoubetis, Vardi, Wolper, Yannakakis).
+// the outer search visits the accepting states in postorder. from each one an inner search looks for
+// a cycle back to it, the states visited by inner searches are not visited again.
+// if the language of A is not empty, an accepting lasso is returned.
+func IsEmpty(A Buchi) (bool, Lasso) {
+	blue := make(map[nfa.State]bool)
+	red := make(map[nfa.State]bool)
+
+	// the path of the outer search, letters[i] leads from states[i] to states[i+1]
+	states := make([]nfa.State, 0)
+	letters := make([]nfa.Letter, 0)
+	cycleStates := make([]nfa.State, 0)
+	cycleLetters := make([]nfa.Letter, 0)
+
+	var seed nfa.State
+
+	var inner func(nfa.State) bool
+	inner = func(q nfa.State) bool {
+		red[q] = true
+		cycleStates = append(cycleStates, q)
+		for _, e := range edges(A, q) {
+			cycleLetters = append(cycleLetters, e.letter)
+			if e.target == seed {
+				return true
+			}
+			if red[e.target] == false && inner(e.target) == true {
+				return true
+			}
+			cycleLetters = cycleLetters[:len(cycleLetters)-1]
+		}
+		cycleStates = cycleStates[:len(cycleStates)-1]
+		return false
+	}
+
+	var outer func(nfa.State) bool
+	outer = func(q nfa.State) bool {
+		blue[q] = true
+		states = append(states, q)
+		for _, e := range edges(A, q) {
+			if blue[e.target] == false {
+				letters = append(letters, e.letter)
+				if outer(e.target) == true {
+					return true
+				}
+				letters = letters[:len(letters)-1]
+			}
+		}
+		if A.AcceptingStates().Probe(q) == true {
+			seed = q
+			if inner(q) == true {
+				return true
+			}
+		}
+		states = states[:len(states)-1]
+		return false
+	}
+
+	for i := 0; i < A.InitialStates().Size(); i += 1 {
+		q, _ := A.InitialStates().At(i)
+		if blue[q.(nfa.State)] == false && outer(q.(nfa.State)) == true {
+			// the seed is the last state of the outer path
+			n := len(states) - 1
+			return false, Lasso{states[:n], cycleStates, letters[:n], cycleLetters}
+		}
+	}
+
+	return true, Lasso{}
+}
+
+// Couvreur's on-the-fly algorithm: a depth first search which keeps a stack of the roots of the
+// strongly connected components that are still being explored, together with the acceptance sets
+// their states belong to. when a back edge merges components whose states hit every acceptance
+// set, there is an accepting cycle.
+// if the language of G is not empty, an accepting lasso is returned.
+func IsEmptyGba(G Gba) (bool, Lasso) {
+	k := len(G.AcceptanceSets())
+
+	acceptance := func(q nfa.State) []bool {
+		ret := make([]bool, k)
+		for i, F := range G.AcceptanceSets() {
+			ret[i] = F.Probe(q)
+		}
+		return ret
+	}
+
+	type root struct {
+		number     int
+		acceptance []bool
+	}
+
+	number := make(map[nfa.State]int) // depth first numbers, starting at 1
+	dead := make(map[nfa.State]bool)  // in a completely explored component
+	active := make([]nfa.State, 0)    // the visited states of components that are not dead
+	roots := make([]root, 0)
+
+	// the path of the search, letters[i] leads from states[i] to states[i+1]
+	states := make([]nfa.State, 0)
+	letters := make([]nfa.Letter, 0)
+
+	found := false
+
+	var visit func(nfa.State)
+	visit = func(q nfa.State) {
+		number[q] = len(number) + 1
+		active = append(active, q)
+		roots = append(roots, root{number[q], acceptance(q)})
+		states = append(states, q)
+
+		for _, e := range edges(G, q) {
+			if dead[e.target] == true {
+				continue
+			}
+
+			if number[e.target] == 0 {
+				letters = append(letters, e.letter)
+				visit(e.target)
+				if found == true {
+					return
+				}
+				letters = letters[:len(letters)-1]
+				continue
+			}
+
+			// a back or cross edge into the component of e.target, which is still active
+			merged := make([]bool, k)
+			for roots[len(roots)-1].number > number[e.target] {
+				for i, x := range roots[len(roots)-1].acceptance {
+					merged[i] = merged[i] || x
+				}
+				roots = roots[:len(roots)-1]
+			}
+			top := &roots[len(roots)-1]
+			complete := true
+			for i := range merged {
+				top.acceptance[i] = top.acceptance[i] || merged[i]
+				complete = complete && top.acceptance[i]
+			}
+			if complete == true {
+				found = true
+				return
+			}
+		}
+
+		if roots[len(roots)-1].number == number[q] {
+			roots = roots[:len(roots)-1]
+			for {
+				r := active[len(active)-1]
+				active = active[:len(active)-1]
+				dead[r] = true
+				if r == q {
+					break
+				}
+			}
+		}
+		states = states[:len(states)-1]
+	}
+
+	for i := 0; i < G.InitialStates().Size() && found == false; i += 1 {
+		q, _ := G.InitialStates().At(i)
+		if number[q.(nfa.State)] == 0 {
+			visit(q.(nfa.State))
+		}
+	}
+
+	if found == false {
+		return true, Lasso{}
+	}
+
+	// the stem leads to the root of the accepting component, whose states are the active ones
+	// with at least its number
+	r := roots[len(roots)-1].number
+	component := make(map[nfa.State]bool)
+	for _, q := range active {
+		if number[q] >= r {
+			component[q] = true
+		}
+	}
+	n := 0
+	for number[states[n]] != r {
+		n += 1
+	}
+
+	cycle, cycleWord := cycleThrough(G, component, states[n])
+	return false, Lasso{states[:n], cycle, letters[:n], cycleWord}
+}
+
+// a cycle from start through every acceptance set which stays inside the strongly connected component.
+// it is put together from shortest paths found by breadth first searches.
+func cycleThrough(G Gba, component map[nfa.State]bool, start nfa.State) ([]nfa.State, []nfa.Letter) {
+	states := []nfa.State{start}
+	letters := make([]nfa.Letter, 0)
+
+	// a shortest path with at least one transition from q to a state for which target holds,
+	// the states after q and the letters are returned
+	path := func(q nfa.State, target func(nfa.State) bool) ([]nfa.State, []nfa.Letter) {
+		type origin struct {
+			predecessor nfa.State
+			letter      nfa.Letter
+		}
+		origins := make(map[nfa.State]origin)
+		queue := make([]nfa.State, 0)
+
+		expand := func(p nfa.State) (nfa.State, bool) {
+			for _, e := range edges(G, p) {
+				if component[e.target] == false {
+					continue
+				}
+				if target(e.target) == true {
+					origins[e.target] = origin{p, e.letter}
+					return e.target, true
+				}
+				if _, ok := origins[e.target]; ok == true || e.target == q {
+					continue
+				}
+				origins[e.target] = origin{p, e.letter}
+				queue = append(queue, e.target)
+			}
+			return "", false
+		}
+
+		end, ok := expand(q)
+		for ok == false {
+			p := queue[0]
+			queue = queue[1:]
+			end, ok = expand(p)
+		}
+
+		ps := []nfa.State{end}
+		ls := []nfa.Letter{origins[end].letter}
+		for r := origins[end].predecessor; r != q; r = origins[r].predecessor {
+			ps = append([]nfa.State{r}, ps...)
+			ls = append([]nfa.Letter{origins[r].letter}, ls...)
+		}
+		return ps, ls
+	}
+
+	for _, F := range G.AcceptanceSets() {
+		visited := false
+		for _, q := range states {
+			visited = visited || F.Probe(q)
+		}
+		if visited == false {
+			ps, ls := path(states[len(states)-1], func(q nfa.State) bool { return F.Probe(q) })
+			states = append(states, ps...)
+			letters = append(letters, ls...)
+		}
+	}
+
+	ps, ls := path(states[len(states)-1], func(q nfa.State) bool { return q == start })
+	states = append(states, ps...)
+	letters = append(letters, ls...)
+
+	return states[:len(states)-1], letters
+}
+
+/*****************************************************************************/
+
+type edge struct {
+	letter nfa.Letter
+	target nfa.State
+}
+
+// what Buchi and Gba have in common
+type transitionSystem interface {
+	Alphabet() nfa.Alphabet
+	Transition(nfa.State, nfa.Letter) nfa.StateSet
+}
+
+// the outgoing transitions of q, in the order of the alphabet
+func edges(A transitionSystem, q nfa.State) []edge {
+	ret := make([]edge, 0)
+	for i := 0; i < A.Alphabet().Size(); i += 1 {
+		a, _ := A.Alphabet().At(i)
+		R := A.Transition(q, a.(nfa.Letter))
+		for j := 0; j < R.Size(); j += 1 {
+			r, _ := R.At(j)
+			ret = append(ret, edge{a.(nfa.Letter), r.(nfa.State)})
+		}
+	}
+	return ret
+}
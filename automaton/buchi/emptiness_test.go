@@ -0,0 +1,148 @@
+package buchi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"github.com/hydroo/gomochex/basic/set"
+	"math/rand"
+	"testing"
+)
+
+func TestIsEmpty(t *testing.T) {
+	A := NewBuchi()
+	json.Unmarshal(finitelyManyA, &A)
+
+	empty, lasso := IsEmpty(A)
+	if empty != false || isAcceptingLasso(A.Nfa(), []nfa.StateSet{A.AcceptingStates()}, lasso) == false {
+		t.Error(lasso)
+	}
+	if l := (Lasso{[]nfa.State{"0"}, []nfa.State{"1"}, []nfa.Letter{"b"}, []nfa.Letter{"b"}}); fmt.Sprint(lasso) != fmt.Sprint(l) {
+		t.Error(lasso)
+	}
+
+	// the accepting state is not on a cycle
+	json.Unmarshal([]byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"1":{"a":["1"]}},"FinalStates":["0"]}`), &A)
+	if empty, _ := IsEmpty(A); empty != true {
+		t.Error(A)
+	}
+	if empty, _ := IsEmpty(NewBuchi()); empty != true {
+		t.Error()
+	}
+
+	// a self loop on an initial state, the stem is empty
+	json.Unmarshal([]byte(`{"States":["0"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["0"]}},"FinalStates":["0"]}`), &A)
+	if _, lasso := IsEmpty(A); fmt.Sprint(lasso) != fmt.Sprint(Lasso{[]nfa.State{}, []nfa.State{"0"}, []nfa.Letter{}, []nfa.Letter{"a"}}) {
+		t.Error(lasso)
+	}
+}
+
+func TestIsEmptyGba(t *testing.T) {
+	A := NewGba()
+	json.Unmarshal(infinitelyManyAAndB, &A)
+
+	empty, lasso := IsEmptyGba(A)
+	if empty != false || isAcceptingLasso(gbaAsNfa(A), A.AcceptanceSets(), lasso) == false {
+		t.Error(lasso)
+	}
+	if l := (Lasso{[]nfa.State{}, []nfa.State{"a", "b"}, []nfa.Letter{}, []nfa.Letter{"b", "a"}}); fmt.Sprint(lasso) != fmt.Sprint(l) {
+		t.Error(lasso)
+	}
+
+	// b is never read again after an a
+	A.Transition("a", "b").Clear()
+	if empty, lasso := IsEmptyGba(A); empty != true {
+		t.Error(lasso)
+	}
+
+	// every cycle is accepting
+	A.SetAcceptanceSets(make([]nfa.StateSet, 0))
+	if empty, lasso := IsEmptyGba(A); empty != false || isAcceptingLasso(gbaAsNfa(A), A.AcceptanceSets(), lasso) == false {
+		t.Error(lasso)
+	}
+}
+
+func TestIsEmptyRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	sigma := set.NewSet(nfa.Letter("a"), nfa.Letter("b"))
+
+	empty, nonEmpty := 0, 0
+	for k := 0; k < 300; k += 1 {
+		N := nfa.Random(3+k%15, sigma, 0.8, 0.2, rng)
+		A, _ := FromNfa(N)
+		G := FromBuchi(A)
+
+		// as many as three acceptance sets
+		H := G.Copy()
+		acceptanceSets := make([]nfa.StateSet, k%4)
+		for l := range acceptanceSets {
+			acceptanceSets[l] = set.NewSet()
+			for i := 0; i < N.States().Size(); i += 1 {
+				if rng.Intn(4) == 0 {
+					q, _ := N.States().At(i)
+					acceptanceSets[l].Add(q)
+				}
+			}
+		}
+		H.SetAcceptanceSets(acceptanceSets)
+
+		expected := Trim(G).States().Size() == 0
+		if expected == true {
+			empty += 1
+		} else {
+			nonEmpty += 1
+		}
+
+		e, lasso := IsEmpty(A)
+		if e != expected || (e == false && isAcceptingLasso(N, []nfa.StateSet{A.AcceptingStates()}, lasso) == false) {
+			t.Error("case", k, "nested dfs", e, lasso, A)
+		}
+		e, lasso = IsEmptyGba(G)
+		if e != expected || (e == false && isAcceptingLasso(N, G.AcceptanceSets(), lasso) == false) {
+			t.Error("case", k, "couvreur", e, lasso, A)
+		}
+
+		e, lasso = IsEmptyGba(H)
+		if e != (Trim(H).States().Size() == 0) || (e == false && isAcceptingLasso(N, H.AcceptanceSets(), lasso) == false) {
+			t.Error("case", k, "couvreur, generalized", e, lasso, H)
+		}
+	}
+
+	if empty < 50 || nonEmpty < 50 {
+		t.Error(empty, nonEmpty)
+	}
+}
+
+// whether the lasso is a run of A that visits every acceptance set on its cycle, and whether A accepts its word
+func isAcceptingLasso(A nfa.Nfa, acceptanceSets []nfa.StateSet, l Lasso) bool {
+	if len(l.Stem) != len(l.StemWord) || len(l.Cycle) != len(l.CycleWord) || len(l.Cycle) == 0 {
+		return false
+	}
+
+	run := append(append(append(make([]nfa.State, 0), l.Stem...), l.Cycle...), l.Cycle[0])
+	word := append(append(make([]nfa.Letter, 0), l.StemWord...), l.CycleWord...)
+	if A.InitialStates().Probe(run[0]) == false {
+		return false
+	}
+	for i, a := range word {
+		if A.Transition(run[i], a).Probe(run[i+1]) == false {
+			return false
+		}
+	}
+
+	for _, F := range acceptanceSets {
+		visited := false
+		for _, q := range l.Cycle {
+			visited = visited || F.Probe(q)
+		}
+		if visited == false {
+			return false
+		}
+	}
+
+	return acceptsLasso(A, acceptanceSets, l.StemWord, l.CycleWord)
+}
+
+func gbaAsNfa(G Gba) nfa.Nfa {
+	return G.(*simpleGba).automaton
+}
@@ -0,0 +1,389 @@
+package buchi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"github.com/hydroo/gomochex/basic/set"
+)
+
+// generalized Büchi automaton.
+// accepts the infinite words with a run that visits every acceptance set infinitely often.
+// without acceptance sets every infinite run is accepting.
+// the JSON is the one of nfa.Nfa, with AcceptanceSets instead of FinalStates.
+type Gba interface {
+	Alphabet() nfa.Alphabet
+	SetAlphabet(nfa.Alphabet)
+
+	InitialStates() nfa.StateSet
+	SetInitialStates(nfa.StateSet)
+
+	AcceptanceSets() []nfa.StateSet
+	SetAcceptanceSets([]nfa.StateSet)
+
+	States() nfa.StateSet
+	SetStates(nfa.StateSet)
+
+	Transition(nfa.State, nfa.Letter) nfa.StateSet
+	SetTransition(nfa.State, nfa.Letter, nfa.StateSet)
+
+	// whether prefix.loop.loop.loop... is accepted
+	// false if loop is empty or a letter is not in the alphabet
+	AcceptsLasso(prefix, loop []nfa.Letter) bool
+
+	// see nfa.Nfa.Validate
+	Validate() error
+
+	Copy() Gba
+
+	String() string
+	json.Marshaler
+	json.Unmarshaler
+}
+
+func NewGba() Gba {
+	return &simpleGba{nfa.NewNfa(), make([]nfa.StateSet, 0)}
+}
+
+// B as generalized Büchi automaton with its accepting states as only acceptance set
+func FromBuchi(B Buchi) Gba {
+	A := B.Nfa()
+	F := A.FinalStates()
+	A.SetFinalStates(set.NewSet())
+	return &simpleGba{A, []nfa.StateSet{F}}
+}
+
+// counter construction: the states are (q,i) where i is the index of the acceptance set
+// which is waited for. leaving a state of the i-th set advances the counter to i+1 mod k.
+// the accepting states are (q,0) with q in the first acceptance set.
+// only the reachable states are constructed.
+func Degeneralize(G Gba) Buchi {
+	acceptanceSets := G.AcceptanceSets()
+	if len(acceptanceSets) == 0 {
+		acceptanceSets = []nfa.StateSet{G.States()}
+	}
+	k := len(acceptanceSets)
+
+	type node struct {
+		q nfa.State
+		i int
+	}
+
+	name := func(v node) nfa.State {
+		return nfa.State(fmt.Sprint("(", v.q, ",", v.i, ")"))
+	}
+
+	B := NewBuchi()
+	B.SetAlphabet(G.Alphabet().Copy().(nfa.Alphabet))
+
+	visited := make(map[node]bool)
+	queue := make([]node, 0)
+	for j := 0; j < G.InitialStates().Size(); j += 1 {
+		q, _ := G.InitialStates().At(j)
+		v := node{q.(nfa.State), 0}
+		visited[v] = true
+		queue = append(queue, v)
+		B.States().Add(name(v))
+		B.InitialStates().Add(name(v))
+	}
+
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+
+		if v.i == 0 && acceptanceSets[0].Probe(v.q) == true {
+			B.AcceptingStates().Add(name(v))
+		}
+
+		i := v.i
+		if acceptanceSets[v.i].Probe(v.q) == true {
+			i = (v.i + 1) % k
+		}
+
+		for j := 0; j < G.Alphabet().Size(); j += 1 {
+			a, _ := G.Alphabet().At(j)
+			R := G.Transition(v.q, a.(nfa.Letter))
+			S := set.NewSet()
+			for l := 0; l < R.Size(); l += 1 {
+				r, _ := R.At(l)
+				w := node{r.(nfa.State), i}
+				if visited[w] == false {
+					visited[w] = true
+					queue = append(queue, w)
+					B.States().Add(name(w))
+				}
+				S.Add(name(w))
+			}
+			B.SetTransition(name(v), a.(nfa.Letter), S)
+		}
+	}
+
+	return B
+}
+
+// the reachable states of G from which some word is accepted.
+// these are the states that reach a strongly connected component with at least one edge
+// which intersects every acceptance set.
+func Trim(G Gba) Gba {
+	n := G.States().Size()
+	states := make([]nfa.State, n)
+	index := make(map[nfa.State]int)
+	for i := 0; i < n; i += 1 {
+		q, _ := G.States().At(i)
+		states[i] = q.(nfa.State)
+		index[q.(nfa.State)] = i
+	}
+
+	successors := make([][]int, n)
+	for i, q := range states {
+		for j := 0; j < G.Alphabet().Size(); j += 1 {
+			a, _ := G.Alphabet().At(j)
+			R := G.Transition(q, a.(nfa.Letter))
+			for k := 0; k < R.Size(); k += 1 {
+				r, _ := R.At(k)
+				if l, ok := index[r.(nfa.State)]; ok == true {
+					successors[i] = append(successors[i], l)
+				}
+			}
+		}
+	}
+	successorsOf := func(i int) []int {
+		return successors[i]
+	}
+
+	roots := make([]int, 0)
+	for i, q := range states {
+		if G.InitialStates().Probe(q) == true {
+			roots = append(roots, i)
+		}
+	}
+
+	// successors come first, so their liveness is known
+	live := make([]bool, n)
+	for _, component := range stronglyConnectedComponents(roots, successorsOf) {
+		accepting := isCycle(component, successorsOf)
+		for _, F := range G.AcceptanceSets() {
+			intersects := false
+			for _, i := range component {
+				if F.Probe(states[i]) == true {
+					intersects = true
+					break
+				}
+			}
+			accepting = accepting && intersects
+		}
+
+		for _, i := range component {
+			for _, j := range successors[i] {
+				accepting = accepting || live[j]
+			}
+		}
+
+		for _, i := range component {
+			live[i] = accepting
+		}
+	}
+
+	restrict := func(S nfa.StateSet) nfa.StateSet {
+		T := set.NewSet()
+		for i := 0; i < S.Size(); i += 1 {
+			q, _ := S.At(i)
+			if j, ok := index[q.(nfa.State)]; ok == true && live[j] == true {
+				T.Add(q)
+			}
+		}
+		return T
+	}
+
+	H := NewGba()
+	H.SetAlphabet(G.Alphabet().Copy().(nfa.Alphabet))
+	H.SetStates(restrict(G.States()))
+	H.SetInitialStates(restrict(G.InitialStates()))
+	for i, q := range states {
+		if live[i] == false {
+			continue
+		}
+		for j := 0; j < G.Alphabet().Size(); j += 1 {
+			a, _ := G.Alphabet().At(j)
+			H.SetTransition(q, a.(nfa.Letter), restrict(G.Transition(q, a.(nfa.Letter))))
+		}
+	}
+	acceptanceSets := make([]nfa.StateSet, len(G.AcceptanceSets()))
+	for k, F := range G.AcceptanceSets() {
+		acceptanceSets[k] = restrict(F)
+	}
+	H.SetAcceptanceSets(acceptanceSets)
+
+	return H
+}
+
+/*****************************************************************************/
+
+// the final states of automaton are unused
+type simpleGba struct {
+	automaton      nfa.Nfa
+	acceptanceSets []nfa.StateSet
+}
+
+func (A simpleGba) Alphabet() nfa.Alphabet {
+	return A.automaton.Alphabet()
+}
+
+func (A *simpleGba) SetAlphabet(sigma nfa.Alphabet) {
+	A.automaton.SetAlphabet(sigma)
+}
+
+func (A simpleGba) InitialStates() nfa.StateSet {
+	return A.automaton.InitialStates()
+}
+
+func (A *simpleGba) SetInitialStates(S nfa.StateSet) {
+	A.automaton.SetInitialStates(S)
+}
+
+func (A simpleGba) AcceptanceSets() []nfa.StateSet {
+	return A.acceptanceSets
+}
+
+func (A *simpleGba) SetAcceptanceSets(F []nfa.StateSet) {
+	A.acceptanceSets = F
+}
+
+func (A simpleGba) States() nfa.StateSet {
+	return A.automaton.States()
+}
+
+func (A *simpleGba) SetStates(S nfa.StateSet) {
+	A.automaton.SetStates(S)
+}
+
+func (A simpleGba) Transition(q nfa.State, a nfa.Letter) nfa.StateSet {
+	return A.automaton.Transition(q, a)
+}
+
+func (A *simpleGba) SetTransition(q nfa.State, a nfa.Letter, S nfa.StateSet) {
+	A.automaton.SetTransition(q, a, S)
+}
+
+// see acceptsLasso
+func (A simpleGba) AcceptsLasso(prefix, loop []nfa.Letter) bool {
+	return acceptsLasso(A.automaton, A.acceptanceSets, prefix, loop)
+}
+
+func (A simpleGba) Validate() error {
+	inconsistencies := make([]nfa.Inconsistency, 0)
+	if err, ok := A.automaton.Validate().(nfa.ValidationError); ok == true {
+		inconsistencies = append(inconsistencies, err.Inconsistencies...)
+	}
+
+	reported := make(map[nfa.Inconsistency]bool)
+	for _, F := range A.acceptanceSets {
+		for i := 0; i < F.Size(); i += 1 {
+			q, _ := F.At(i)
+			inconsistency := nfa.Inconsistency{Field: "AcceptanceSets", Undeclared: "state", Name: string(q.(nfa.State))}
+			if A.States().Probe(q) == false && reported[inconsistency] == false {
+				reported[inconsistency] = true
+				inconsistencies = append(inconsistencies, inconsistency)
+			}
+		}
+	}
+
+	if len(inconsistencies) > 0 {
+		return nfa.ValidationError{Inconsistencies: inconsistencies}
+	}
+	return nil
+}
+
+func (A simpleGba) Copy() Gba {
+	acceptanceSets := make([]nfa.StateSet, len(A.acceptanceSets))
+	for k, F := range A.acceptanceSets {
+		acceptanceSets[k] = F.Copy().(nfa.StateSet)
+	}
+	return &simpleGba{A.automaton.Copy(), acceptanceSets}
+}
+
+func (A simpleGba) String() string {
+	ret := ""
+	ret += fmt.Sprintln("states:", A.States())
+	ret += fmt.Sprintln("alphabet:", A.Alphabet())
+	ret += fmt.Sprintln("initial states:", A.InitialStates())
+	ret += fmt.Sprintln("acceptance sets:")
+	for _, F := range A.acceptanceSets {
+		ret += fmt.Sprintln(" ", F)
+	}
+	ret += fmt.Sprintln("transitions:")
+	for i := 0; i < A.States().Size(); i += 1 {
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
+			s, _ := A.States().At(i)
+			a, _ := A.Alphabet().At(j)
+			if S := A.Transition(s.(nfa.State), a.(nfa.Letter)); S.Size() > 0 {
+				ret += fmt.Sprintln(" ", s, "--", a, "-->", S)
+			}
+		}
+	}
+
+	return ret
+}
+
+func (A simpleGba) MarshalJSON() ([]byte, error) {
+	type simpleGbaWithExportedFields struct {
+		States         nfa.StateSet
+		Alphabet       nfa.Alphabet
+		InitialStates  nfa.StateSet
+		Transitions    map[nfa.State]map[nfa.Letter]nfa.StateSet
+		AcceptanceSets []nfa.StateSet
+	}
+
+	transitions := make(map[nfa.State]map[nfa.Letter]nfa.StateSet)
+	for i := 0; i < A.States().Size(); i += 1 {
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
+			q, _ := A.States().At(i)
+			a, _ := A.Alphabet().At(j)
+			if S := A.Transition(q.(nfa.State), a.(nfa.Letter)); S.Size() > 0 {
+				if _, ok := transitions[q.(nfa.State)]; ok == false {
+					transitions[q.(nfa.State)] = make(map[nfa.Letter]nfa.StateSet)
+				}
+				transitions[q.(nfa.State)][a.(nfa.Letter)] = S
+			}
+		}
+	}
+
+	return json.Marshal(simpleGbaWithExportedFields{A.States(), A.Alphabet(), A.InitialStates(), transitions, A.acceptanceSets})
+}
+
+// A is only changed if b is valid and has neither epsilon transitions nor final states
+func (A *simpleGba) UnmarshalJSON(b []byte) error {
+	B := nfa.NewNfa()
+	if err := json.Unmarshal(b, &B); err != nil {
+		return err
+	}
+	if nfa.HasEpsilonTransitions(B) == true {
+		return errors.New("Büchi automata have no epsilon transitions")
+	}
+	if B.FinalStates().Size() > 0 {
+		return errors.New("generalized Büchi automata have AcceptanceSets instead of FinalStates")
+	}
+
+	var C struct {
+		AcceptanceSets [][]nfa.State
+	}
+	if err := json.Unmarshal(b, &C); err != nil {
+		return err
+	}
+
+	acceptanceSets := make([]nfa.StateSet, len(C.AcceptanceSets))
+	for k, F := range C.AcceptanceSets {
+		acceptanceSets[k] = set.NewSet()
+		for _, q := range F {
+			acceptanceSets[k].Add(q)
+		}
+	}
+
+	G := &simpleGba{B, acceptanceSets}
+	if err := G.Validate(); err != nil {
+		return err
+	}
+	*A = *G
+	return nil
+}
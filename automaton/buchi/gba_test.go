@@ -0,0 +1,193 @@
+package buchi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"github.com/hydroo/gomochex/basic/set"
+	"math/rand"
+	"testing"
+)
+
+// infinitely many a and infinitely many b, the state is the last letter
+var infinitelyManyAAndB = []byte(`{"States":["a","b"],"Alphabet":["a","b"],"InitialStates":["a"],"Transitions":{"a":{"a":["a"],"b":["b"]},"b":{"a":["a"],"b":["b"]}},"AcceptanceSets":[["a"],["b"]]}`)
+
+func TestGbaAcceptsLasso(t *testing.T) {
+	A := NewGba()
+	if err := json.Unmarshal(infinitelyManyAAndB, &A); err != nil {
+		t.Fatal(err)
+	}
+
+	type test struct {
+		prefix, loop []nfa.Letter
+		accepted     bool
+	}
+
+	tests := []test{
+		test{nil, []nfa.Letter{"a", "b"}, true},
+		test{[]nfa.Letter{"a", "a"}, []nfa.Letter{"b", "b", "a"}, true},
+		test{[]nfa.Letter{"b"}, []nfa.Letter{"a"}, false},
+		test{[]nfa.Letter{"a"}, []nfa.Letter{"b"}, false},
+		test{[]nfa.Letter{"a"}, []nfa.Letter{}, false},
+		test{nil, []nfa.Letter{"a", "c"}, false},
+	}
+
+	for k, x := range tests {
+		if A.AcceptsLasso(x.prefix, x.loop) != x.accepted {
+			t.Error("case", k)
+		}
+	}
+
+	// without acceptance sets every infinite run is accepting
+	A.SetAcceptanceSets(make([]nfa.StateSet, 0))
+	if A.AcceptsLasso([]nfa.Letter{"b"}, []nfa.Letter{"a"}) != true {
+		t.Error(A)
+	}
+}
+
+func TestGbaJson(t *testing.T) {
+	A := NewGba()
+	if err := json.Unmarshal(infinitelyManyAAndB, &A); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := json.Marshal(A)
+	if err != nil || string(s) != string(infinitelyManyAAndB) {
+		t.Error(err, string(s))
+	}
+
+	if len(A.AcceptanceSets()) != 2 || A.AcceptanceSets()[1].IsEqual(set.NewSet(nfa.State("b"))) != true || A.Validate() != nil {
+		t.Error(A)
+	}
+
+	s, _ = json.Marshal(NewGba())
+	if string(s) != `{"States":[],"Alphabet":[],"InitialStates":[],"Transitions":{},"AcceptanceSets":[]}` {
+		t.Error(string(s))
+	}
+
+	type test struct {
+		b []byte
+		e string
+	}
+
+	tests := []test{
+		test{[]byte(`{"States":["0"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{},"AcceptanceSets":[["0"],["1"]]}`), "invalid nfa: undeclared state 1 in AcceptanceSets"},
+		test{[]byte(`{"States":["0"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{},"FinalStates":["0"]}`), "generalized Büchi automata have AcceptanceSets instead of FinalStates"},
+		test{[]byte(`{"States":["0"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{},"EpsilonTransitions":{"0":["0"]}}`), "Büchi automata have no epsilon transitions"},
+	}
+
+	for k, x := range tests {
+		B := A.Copy()
+		if err := json.Unmarshal(x.b, &B); err == nil || err.Error() != x.e {
+			t.Error("case", k, err)
+		}
+		if s, _ := json.Marshal(B); string(s) != string(infinitelyManyAAndB) {
+			t.Error("case", k, string(s))
+		}
+	}
+}
+
+func TestGbaCopy(t *testing.T) {
+	A := NewGba()
+	json.Unmarshal(infinitelyManyAAndB, &A)
+
+	B := A.Copy()
+	B.AcceptanceSets()[0].Add(nfa.State("b"))
+	B.States().Add(nfa.State("c"))
+	if A.AcceptanceSets()[0].Size() != 1 || A.States().Size() != 2 {
+		t.Error(A)
+	}
+}
+
+func TestDegeneralize(t *testing.T) {
+	A := NewGba()
+	json.Unmarshal(infinitelyManyAAndB, &A)
+
+	B := Degeneralize(A)
+	s, _ := json.Marshal(B)
+	if string(s) != `{"States":["(a,0)","(a,1)","(b,1)","(b,0)"],"Alphabet":["a","b"],"InitialStates":["(a,0)"],"Transitions":{"(a,0)":{"a":["(a,1)"],"b":["(b,1)"]},"(a,1)":{"a":["(a,1)"],"b":["(b,1)"]},"(b,0)":{"a":["(a,0)"],"b":["(b,0)"]},"(b,1)":{"a":["(a,0)"],"b":["(b,0)"]}},"FinalStates":["(a,0)"]}` {
+		t.Error(string(s))
+	}
+
+	// a single acceptance set keeps the automaton
+	C := NewBuchi()
+	json.Unmarshal(finitelyManyA, &C)
+	if Degeneralize(FromBuchi(C)).Nfa().IsEqual(C.Nfa()) != true {
+		t.Error(Degeneralize(FromBuchi(C)))
+	}
+}
+
+func TestDegeneralizeRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	sigma := set.NewSet(nfa.Letter("a"), nfa.Letter("b"))
+
+	// all words of length 0 to 3
+	words := [][]nfa.Letter{[]nfa.Letter{}}
+	for i := 0; i < len(words); i += 1 {
+		if len(words[i]) < 3 {
+			for _, a := range []nfa.Letter{"a", "b"} {
+				words = append(words, append(append(make([]nfa.Letter, 0), words[i]...), a))
+			}
+		}
+	}
+
+	for k := 0; k < 50; k += 1 {
+		N := nfa.Random(5, sigma, 1.3, 0, rng)
+		A := NewGba()
+		A.SetStates(N.States())
+		A.SetAlphabet(N.Alphabet())
+		A.SetInitialStates(N.InitialStates())
+		for i := 0; i < N.States().Size(); i += 1 {
+			q, _ := N.States().At(i)
+			for j := 0; j < sigma.Size(); j += 1 {
+				a, _ := sigma.At(j)
+				A.SetTransition(q.(nfa.State), a.(nfa.Letter), N.Transition(q.(nfa.State), a.(nfa.Letter)))
+			}
+		}
+
+		acceptanceSets := make([]nfa.StateSet, k%4)
+		for l := range acceptanceSets {
+			acceptanceSets[l] = set.NewSet()
+			for i := 0; i < N.States().Size(); i += 1 {
+				if rng.Intn(3) == 0 {
+					q, _ := N.States().At(i)
+					acceptanceSets[l].Add(q)
+				}
+			}
+		}
+		A.SetAcceptanceSets(acceptanceSets)
+
+		B := Degeneralize(A)
+		if B.Validate() != nil {
+			t.Error("case", k, B.Validate())
+		}
+
+		for _, prefix := range words {
+			for _, loop := range words[1:] {
+				if A.AcceptsLasso(prefix, loop) != B.AcceptsLasso(prefix, loop) {
+					t.Error("case", k, fmt.Sprint(prefix, loop), A, B)
+				}
+			}
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	// 1 is unreachable, 2 cannot visit the second acceptance set again, 3 has no successors
+	A := NewGba()
+	if err := json.Unmarshal([]byte(`{"States":["0","1","2","3","4"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["2","3","4"],"b":["0"]},"1":{"a":["0"]},"2":{"a":["2"]},"4":{"a":["4"],"b":["0"]}},"AcceptanceSets":[["0","2"],["4"]]}`), &A); err != nil {
+		t.Fatal(err)
+	}
+
+	B := Trim(A)
+	s, _ := json.Marshal(B)
+	if string(s) != `{"States":["0","4"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["4"],"b":["0"]},"4":{"a":["4"],"b":["0"]}},"AcceptanceSets":[["0"],["4"]]}` {
+		t.Error(string(s))
+	}
+
+	// the language is empty
+	A.AcceptanceSets()[1].Remove(nfa.State("4"))
+	if s, _ := json.Marshal(Trim(A)); string(s) != `{"States":[],"Alphabet":["a","b"],"InitialStates":[],"Transitions":{},"AcceptanceSets":[[],[]]}` {
+		t.Error(string(s))
+	}
+}
@@ -0,0 +1,152 @@
+package buchi
+
+import (
+	"github.com/hydroo/gomochex/automaton/nfa"
+)
+
+// whether prefix.loop.loop... has a run from an initial state that visits every acceptance set infinitely often.
+//
+// the nodes (q, i) of the product with the lasso are a state and a position in prefix.loop,
+// after the last position of the loop follows the first one. the word is accepted iff a reachable
+// strongly connected component with at least one edge intersects every acceptance set.
+func acceptsLasso(A nfa.Nfa, acceptanceSets []nfa.StateSet, prefix, loop []nfa.Letter) bool {
+	if len(loop) == 0 {
+		return false
+	}
+
+	word := append(append(make([]nfa.Letter, 0, len(prefix)+len(loop)), prefix...), loop...)
+	for _, a := range word {
+		if A.Alphabet().Probe(a) == false {
+			return false
+		}
+	}
+
+	type node struct {
+		q nfa.State
+		i int
+	}
+
+	// the nodes are numbered when they are found
+	nodes := make([]node, 0)
+	id := make(map[node]int)
+	number := func(v node) int {
+		if k, ok := id[v]; ok == true {
+			return k
+		}
+		id[v] = len(nodes)
+		nodes = append(nodes, v)
+		return len(nodes) - 1
+	}
+
+	successors := func(k int) []int {
+		v := nodes[k]
+		j := v.i + 1
+		if j == len(word) {
+			j = len(prefix)
+		}
+		ret := make([]int, 0)
+		Q := A.Transition(v.q, word[v.i])
+		for l := 0; l < Q.Size(); l += 1 {
+			r, _ := Q.At(l)
+			ret = append(ret, number(node{r.(nfa.State), j}))
+		}
+		return ret
+	}
+
+	roots := make([]int, 0)
+	for k := 0; k < A.InitialStates().Size(); k += 1 {
+		q, _ := A.InitialStates().At(k)
+		roots = append(roots, number(node{q.(nfa.State), 0}))
+	}
+
+	for _, component := range stronglyConnectedComponents(roots, successors) {
+		if isCycle(component, successors) == false {
+			continue
+		}
+		accepting := true
+		for _, F := range acceptanceSets {
+			intersects := false
+			for _, k := range component {
+				if F.Probe(nodes[k].q) == true {
+					intersects = true
+					break
+				}
+			}
+			if intersects == false {
+				accepting = false
+				break
+			}
+		}
+		if accepting == true {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Tarjan's algorithm on the nodes which are reachable from roots.
+// the components are returned in reverse topological order, i.e. successors first.
+func stronglyConnectedComponents(roots []int, successors func(int) []int) [][]int {
+	index := make(map[int]int)
+	lowlink := make(map[int]int)
+	onStack := make(map[int]bool)
+	stack := make([]int, 0)
+	components := make([][]int, 0)
+
+	var visit func(int)
+	visit = func(v int) {
+		index[v] = len(index)
+		lowlink[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range successors(v) {
+			if _, ok := index[w]; ok == false {
+				visit(w)
+				if lowlink[w] < lowlink[v] {
+					lowlink[v] = lowlink[w]
+				}
+			} else if onStack[w] == true && index[w] < lowlink[v] {
+				lowlink[v] = index[w]
+			}
+		}
+
+		if lowlink[v] != index[v] {
+			return
+		}
+
+		component := make([]int, 0)
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		components = append(components, component)
+	}
+
+	for _, v := range roots {
+		if _, ok := index[v]; ok == false {
+			visit(v)
+		}
+	}
+
+	return components
+}
+
+// whether the strongly connected component has at least one edge
+func isCycle(component []int, successors func(int) []int) bool {
+	if len(component) > 1 {
+		return true
+	}
+	for _, w := range successors(component[0]) {
+		if w == component[0] {
+			return true
+		}
+	}
+	return false
+}
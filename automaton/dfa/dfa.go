@@ -0,0 +1,302 @@
+package dfa
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"github.com/hydroo/gomochex/basic/set"
+	"sort"
+)
+
+// the transition function is total:
+// every missing transition leads to the sink state
+type Dfa interface {
+	Alphabet() nfa.Alphabet
+	SetAlphabet(nfa.Alphabet)
+
+	InitialState() nfa.State
+	SetInitialState(nfa.State)
+
+	FinalStates() nfa.StateSet
+	SetFinalStates(nfa.StateSet)
+
+	States() nfa.StateSet
+	SetStates(nfa.StateSet)
+
+	// the non-final state which only loops to itself
+	SinkState() nfa.State
+	SetSinkState(nfa.State)
+
+	Transition(nfa.State, nfa.Letter) nfa.State
+	SetTransition(nfa.State, nfa.Letter, nfa.State)
+
+	// the set of nfa states a state was constructed from by Determinize
+	Subset(nfa.State) nfa.StateSet
+
+	Accepts([]nfa.Letter) bool
+
+	Nfa() nfa.Nfa
+
+	String() string
+	json.Marshaler
+	json.Unmarshaler
+}
+
+func NewDfa() Dfa {
+	return &simpleDfa{set.NewSet(), set.NewSet(), "", make(map[nfa.State]map[nfa.Letter]nfa.State), set.NewSet(), "", make(map[nfa.State]nfa.StateSet)}
+}
+
+// subset construction
+// only the subsets reachable from the initial states are built.
+// the empty subset becomes the sink state, even if it is unreachable.
+func Determinize(A nfa.Nfa) Dfa {
+	D := NewDfa().(*simpleDfa)
+	D.alphabet = A.Alphabet().Copy().(nfa.Alphabet)
+
+	names := make(map[string]nfa.State)
+	queue := make([]nfa.StateSet, 0)
+
+	var add func(nfa.StateSet) nfa.State
+	add = func(S nfa.StateSet) nfa.State {
+		k := subsetKey(S)
+		if q, ok := names[k]; ok == true {
+			return q
+		}
+
+		q := nfa.State(fmt.Sprint(len(names)))
+		names[k] = q
+
+		D.states.Add(q)
+		D.subsets[q] = S
+		if set.Intersect(S, A.FinalStates()).Size() > 0 {
+			D.finalStates.Add(q)
+		}
+
+		queue = append(queue, S)
+		return q
+	}
+
+	var processQueue func()
+	processQueue = func() {
+		for len(queue) > 0 {
+			S := queue[0]
+			queue = queue[1:]
+			q := names[subsetKey(S)]
+
+			for i := 0; i < D.alphabet.Size(); i += 1 {
+				a, _ := D.alphabet.At(i)
+
+				T := set.NewSet()
+				for j := 0; j < S.Size(); j += 1 {
+					s, _ := S.At(j)
+					T = set.Join(T, A.Transition(s.(nfa.State), a.(nfa.Letter)))
+				}
+
+				D.SetTransition(q, a.(nfa.Letter), add(A.EpsilonClosure(T)))
+			}
+		}
+	}
+
+	D.initialState = add(A.EpsilonClosure(A.InitialStates()))
+	processQueue()
+
+	D.sinkState = add(set.NewSet())
+	processQueue()
+
+	return D
+}
+
+// sorted and quoted state names, so that equal subsets get equal keys
+func subsetKey(S nfa.StateSet) string {
+	names := make([]string, S.Size())
+	for i := 0; i < S.Size(); i += 1 {
+		s, _ := S.At(i)
+		names[i] = string(s.(nfa.State))
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%q", names)
+}
+
+/*****************************************************************************/
+
+type simpleDfa struct {
+	states       nfa.StateSet
+	alphabet     nfa.Alphabet
+	initialState nfa.State
+	transitions  map[nfa.State]map[nfa.Letter]nfa.State
+	finalStates  nfa.StateSet
+	sinkState    nfa.State
+	subsets      map[nfa.State]nfa.StateSet
+}
+
+func (D simpleDfa) Alphabet() nfa.Alphabet {
+	return D.alphabet
+}
+
+func (D *simpleDfa) SetAlphabet(sigma nfa.Alphabet) {
+	D.alphabet = sigma
+}
+
+func (D simpleDfa) InitialState() nfa.State {
+	return D.initialState
+}
+
+func (D *simpleDfa) SetInitialState(q nfa.State) {
+	D.initialState = q
+}
+
+func (D simpleDfa) FinalStates() nfa.StateSet {
+	return D.finalStates
+}
+
+func (D *simpleDfa) SetFinalStates(F nfa.StateSet) {
+	D.finalStates = F
+}
+
+func (D simpleDfa) States() nfa.StateSet {
+	return D.states
+}
+
+func (D *simpleDfa) SetStates(S nfa.StateSet) {
+	D.states = S
+}
+
+func (D simpleDfa) SinkState() nfa.State {
+	return D.sinkState
+}
+
+func (D *simpleDfa) SetSinkState(q nfa.State) {
+	D.sinkState = q
+}
+
+func (D simpleDfa) Transition(q nfa.State, a nfa.Letter) nfa.State {
+	if r, ok := D.transitions[q][a]; ok == true {
+		return r
+	} //else {
+	return D.sinkState
+	//}
+}
+
+func (D *simpleDfa) SetTransition(q nfa.State, a nfa.Letter, r nfa.State) {
+	if _, ok := D.transitions[q]; ok == false {
+		D.transitions[q] = make(map[nfa.Letter]nfa.State)
+	}
+	D.transitions[q][a] = r
+}
+
+func (D simpleDfa) Subset(q nfa.State) nfa.StateSet {
+	if S, ok := D.subsets[q]; ok == true {
+		return S
+	} //else {
+	return set.NewSet()
+	//}
+}
+
+func (D simpleDfa) Accepts(w []nfa.Letter) bool {
+	q := D.initialState
+	for _, a := range w {
+		if D.alphabet.Probe(a) == false {
+			return false
+		}
+		q = D.Transition(q, a)
+	}
+	return D.finalStates.Probe(q)
+}
+
+// the sink state and all transitions into it are kept
+func (D simpleDfa) Nfa() nfa.Nfa {
+	A := nfa.NewNfa()
+	A.SetStates(D.states.Copy().(nfa.StateSet))
+	A.SetAlphabet(D.alphabet.Copy().(nfa.Alphabet))
+	A.InitialStates().Add(D.initialState)
+	A.SetFinalStates(D.finalStates.Copy().(nfa.StateSet))
+
+	for i := 0; i < D.states.Size(); i += 1 {
+		for j := 0; j < D.alphabet.Size(); j += 1 {
+			q, _ := D.states.At(i)
+			a, _ := D.alphabet.At(j)
+			A.SetTransition(q.(nfa.State), a.(nfa.Letter), set.NewSet(D.Transition(q.(nfa.State), a.(nfa.Letter))))
+		}
+	}
+
+	return A
+}
+
+func (D simpleDfa) String() string {
+	ret := ""
+	ret += fmt.Sprintln("states:", D.States())
+	ret += fmt.Sprintln("alphabet:", D.Alphabet())
+	ret += fmt.Sprintln("initial state:", D.InitialState())
+	ret += fmt.Sprintln("final states:", D.FinalStates())
+	ret += fmt.Sprintln("sink state:", D.SinkState())
+	ret += fmt.Sprintln("subsets:")
+	for i := 0; i < D.States().Size(); i += 1 {
+		s, _ := D.States().At(i)
+		if S, ok := D.subsets[s.(nfa.State)]; ok == true {
+			ret += fmt.Sprintln(" ", s, "=", S)
+		}
+	}
+	ret += fmt.Sprintln("transitions:")
+	for i := 0; i < D.States().Size(); i += 1 {
+		for j := 0; j < D.Alphabet().Size(); j += 1 {
+			s, _ := D.States().At(i)
+			a, _ := D.Alphabet().At(j)
+			ret += fmt.Sprintln(" ", s, "--", a, "-->", D.Transition(s.(nfa.State), a.(nfa.Letter)))
+		}
+	}
+
+	return ret
+}
+
+func (D simpleDfa) MarshalJSON() ([]byte, error) {
+	type simpleDfaWithExportedFields struct {
+		States       nfa.StateSet
+		Alphabet     nfa.Alphabet
+		InitialState nfa.State
+		Transitions  map[nfa.State]map[nfa.Letter]nfa.State
+		FinalStates  nfa.StateSet
+		SinkState    nfa.State
+	}
+	return json.Marshal(simpleDfaWithExportedFields{D.states, D.alphabet, D.initialState, D.transitions, D.finalStates, D.sinkState})
+}
+
+func (D *simpleDfa) UnmarshalJSON(b []byte) error {
+	type simpleDfaForUnmarshaling struct {
+		States       []nfa.State
+		Alphabet     []nfa.Letter
+		InitialState nfa.State
+		Transitions  map[string]map[string]string
+		FinalStates  []nfa.State
+		SinkState    nfa.State
+	}
+
+	var E simpleDfaForUnmarshaling
+	if err := json.Unmarshal(b, &E); err != nil {
+		return err
+	}
+
+	states := set.NewSet()
+	alphabet := set.NewSet()
+	transitions := make(map[nfa.State]map[nfa.Letter]nfa.State)
+	finalStates := set.NewSet()
+
+	for _, s := range E.States {
+		states.Add(s)
+	}
+	for _, l := range E.Alphabet {
+		alphabet.Add(l)
+	}
+	for _, s := range E.FinalStates {
+		finalStates.Add(s)
+	}
+	for k, v := range E.Transitions {
+		transitions[nfa.State(k)] = make(map[nfa.Letter]nfa.State)
+		for l, w := range v {
+			transitions[nfa.State(k)][nfa.Letter(l)] = nfa.State(w)
+		}
+	}
+
+	*D = simpleDfa{states, alphabet, E.InitialState, transitions, finalStates, E.SinkState, make(map[nfa.State]nfa.StateSet)}
+
+	return nil
+}
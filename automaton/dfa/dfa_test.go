@@ -0,0 +1,106 @@
+package dfa
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"github.com/hydroo/gomochex/basic/set"
+	"testing"
+)
+
+// (a+b)*.a.(a+b)
+var secondToLastIsA = []byte(`{"States":["0","1","2"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["0","1"],"b":["0"]},"1":{"a":["2"],"b":["2"]}},"FinalStates":["2"]}`)
+
+func TestDeterminize(t *testing.T) {
+	A := nfa.NewNfa()
+	json.Unmarshal(secondToLastIsA, &A)
+
+	D := Determinize(A)
+
+	s := []byte(`{"States":["0","1","2","3","4"],"Alphabet":["a","b"],"InitialState":"0","Transitions":{"0":{"a":"1","b":"0"},"1":{"a":"2","b":"3"},"2":{"a":"2","b":"3"},"3":{"a":"1","b":"0"},"4":{"a":"4","b":"4"}},"FinalStates":["2","3"],"SinkState":"4"}`)
+	if u, err := json.Marshal(D); err != nil || bytes.Compare(s, u) != 0 {
+		t.Error("\nshould:", string(s), "\nis:    ", string(u))
+	}
+
+	subsets := map[nfa.State]nfa.StateSet{
+		"0": set.NewSet(nfa.State("0")),
+		"1": set.NewSet(nfa.State("0"), nfa.State("1")),
+		"2": set.NewSet(nfa.State("0"), nfa.State("1"), nfa.State("2")),
+		"3": set.NewSet(nfa.State("0"), nfa.State("2")),
+		"4": set.NewSet(),
+	}
+	for q, S := range subsets {
+		if D.Subset(q).IsEqual(S) != true {
+			t.Error(q, D.Subset(q), S)
+		}
+	}
+
+	compareLanguages(A, D, 6, t)
+}
+
+func TestDeterminizeConcatUnionKleeneStar(t *testing.T) {
+	A := nfa.Concat(nfa.KleeneStar(nfa.Union(nfa.OneLetter("a"), nfa.OneLetter("b"))), nfa.Concat(nfa.OneLetter("b"), nfa.OneLetter("a")))
+	D := Determinize(A)
+
+	if D.States().Probe(D.SinkState()) != true || D.FinalStates().Probe(D.SinkState()) != false || D.Subset(D.SinkState()).Size() != 0 {
+		t.Error(D)
+	}
+
+	compareLanguages(A, D, 6, t)
+}
+
+func TestDeterminizeEpsilonTransitions(t *testing.T) {
+	// (a)*.b with epsilon transitions
+	A := nfa.NewNfa()
+	json.Unmarshal([]byte(`{"States":["0","1","2","3"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"1":{"a":["1"]},"2":{"b":["3"]}},"EpsilonTransitions":{"0":["1"],"1":["2"]},"FinalStates":["3"]}`), &A)
+
+	D := Determinize(A)
+
+	if D.Subset(D.InitialState()).IsEqual(set.NewSet(nfa.State("0"), nfa.State("1"), nfa.State("2"))) != true {
+		t.Error(D)
+	}
+
+	compareLanguages(A, D, 5, t)
+}
+
+func TestDfaJson(t *testing.T) {
+	A := nfa.NewNfa()
+	json.Unmarshal(secondToLastIsA, &A)
+
+	s1, err1 := json.Marshal(Determinize(A))
+	if err1 != nil {
+		t.Error(err1)
+	}
+
+	D := NewDfa()
+	if err2 := json.Unmarshal(s1, &D); err2 != nil {
+		t.Error(err2)
+	}
+
+	s2, err3 := json.Marshal(D)
+	if err3 != nil {
+		t.Error(err3)
+	}
+
+	if bytes.Compare(s1, s2) != 0 {
+		t.Error("\nshould:", string(s1), "\nis:    ", string(s2))
+	}
+}
+
+// compares the acceptance of all words up to length n
+func compareLanguages(A nfa.Nfa, D Dfa, n int, t *testing.T) {
+	words := [][]nfa.Letter{[]nfa.Letter{}}
+	for length := 0; length <= n; length += 1 {
+		next := make([][]nfa.Letter, 0)
+		for _, w := range words {
+			if A.Accepts(w) != D.Accepts(w) || D.Nfa().Accepts(w) != D.Accepts(w) {
+				t.Error(w, A.Accepts(w), D.Accepts(w))
+			}
+			for i := 0; i < A.Alphabet().Size(); i += 1 {
+				a, _ := A.Alphabet().At(i)
+				next = append(next, append(append([]nfa.Letter{}, w...), a.(nfa.Letter)))
+			}
+		}
+		words = next
+	}
+}
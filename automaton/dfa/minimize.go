@@ -0,0 +1,216 @@
+package dfa
+
+import (
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"sort"
+)
+
+// Hopcroft's partition refinement
+//
+// unreachable states are dropped. the states of the result are named "0", "1", ...
+// in breadth first order from the initial state, visiting successors in sorted letter order.
+// if the sink state is not reachable it comes last.
+// hence language equivalent dfas over the same alphabet minimize to identical dfas.
+func Minimize(D Dfa) Dfa {
+	letters := sortedLetters(D.Alphabet())
+
+	// reachable states in breadth first order, plus the sink state
+	index := make(map[nfa.State]int)
+	states := make([]nfa.State, 0)
+
+	var visit func(nfa.State)
+	visit = func(q nfa.State) {
+		if _, ok := index[q]; ok == false {
+			index[q] = len(states)
+			states = append(states, q)
+		}
+	}
+
+	visit(D.InitialState())
+	for i := 0; i < len(states); i += 1 {
+		for _, a := range letters {
+			visit(D.Transition(states[i], a))
+		}
+	}
+	visit(D.SinkState())
+
+	n := len(states)
+
+	successors := make([][]int, n)
+	predecessors := make([][][]int, len(letters)) // predecessors[c][j] = {i | succ(i, c) = j}
+	for c := range letters {
+		predecessors[c] = make([][]int, n)
+	}
+	for i, q := range states {
+		successors[i] = make([]int, len(letters))
+		for c, a := range letters {
+			j := index[D.Transition(q, a)]
+			successors[i][c] = j
+			predecessors[c][j] = append(predecessors[c][j], i)
+		}
+	}
+
+	// initial partition: final and non-final states
+	blockOf := make([]int, n)
+	blocks := make([][]int, 0)
+
+	finals := make([]int, 0)
+	others := make([]int, 0)
+	for i, q := range states {
+		if D.FinalStates().Probe(q) == true {
+			finals = append(finals, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+	for _, B := range [][]int{finals, others} {
+		if len(B) > 0 {
+			for _, i := range B {
+				blockOf[i] = len(blocks)
+			}
+			blocks = append(blocks, B)
+		}
+	}
+
+	type splitter struct {
+		block, letter int
+	}
+
+	waiting := make([]splitter, 0)
+	isWaiting := make(map[splitter]bool)
+
+	var wait func(splitter)
+	wait = func(s splitter) {
+		if isWaiting[s] == false {
+			isWaiting[s] = true
+			waiting = append(waiting, s)
+		}
+	}
+
+	if len(blocks) == 2 {
+		smaller := 0
+		if len(blocks[1]) < len(blocks[0]) {
+			smaller = 1
+		}
+		for c := range letters {
+			wait(splitter{smaller, c})
+		}
+	}
+
+	for len(waiting) > 0 {
+		s := waiting[len(waiting)-1]
+		waiting = waiting[:len(waiting)-1]
+		isWaiting[s] = false
+
+		// the states of each block with an s.letter transition into s.block
+		marked := make(map[int][]int)
+		for _, j := range blocks[s.block] {
+			for _, i := range predecessors[s.letter][j] {
+				marked[blockOf[i]] = append(marked[blockOf[i]], i)
+			}
+		}
+
+		affected := make([]int, 0, len(marked))
+		for y := range marked {
+			affected = append(affected, y)
+		}
+		sort.Ints(affected)
+
+		for _, y := range affected {
+			inside := marked[y]
+			if len(inside) == len(blocks[y]) {
+				continue
+			}
+
+			isInside := make(map[int]bool)
+			for _, i := range inside {
+				isInside[i] = true
+			}
+			outside := make([]int, 0, len(blocks[y])-len(inside))
+			for _, i := range blocks[y] {
+				if isInside[i] == false {
+					outside = append(outside, i)
+				}
+			}
+
+			z := len(blocks)
+			blocks[y] = outside
+			blocks = append(blocks, inside)
+			for _, i := range inside {
+				blockOf[i] = z
+			}
+
+			for c := range letters {
+				if isWaiting[splitter{y, c}] == true || len(inside) <= len(outside) {
+					wait(splitter{z, c})
+				} else {
+					wait(splitter{y, c})
+				}
+			}
+		}
+	}
+
+	// canonical numbering of the blocks
+	name := make(map[int]nfa.State)
+	order := make([]int, 0, len(blocks))
+
+	var number func(int)
+	number = func(b int) {
+		if _, ok := name[b]; ok == false {
+			name[b] = nfa.State(fmt.Sprint(len(order)))
+			order = append(order, b)
+		}
+	}
+
+	number(blockOf[index[D.InitialState()]])
+	for i := 0; i < len(order); i += 1 {
+		representative := blocks[order[i]][0]
+		for c := range letters {
+			number(blockOf[successors[representative][c]])
+		}
+	}
+	number(blockOf[index[D.SinkState()]])
+
+	M := NewDfa().(*simpleDfa)
+	for _, a := range letters {
+		M.alphabet.Add(a)
+	}
+	for _, b := range order {
+		q := name[b]
+		M.states.Add(q)
+
+		representative := blocks[b][0]
+		if D.FinalStates().Probe(states[representative]) == true {
+			M.finalStates.Add(q)
+		}
+		for c, a := range letters {
+			M.SetTransition(q, a, name[blockOf[successors[representative][c]]])
+		}
+	}
+	M.initialState = name[blockOf[index[D.InitialState()]]]
+	M.sinkState = name[blockOf[index[D.SinkState()]]]
+
+	return M
+}
+
+func sortedLetters(sigma nfa.Alphabet) []nfa.Letter {
+	names := make([]string, sigma.Size())
+	for i := 0; i < sigma.Size(); i += 1 {
+		a, _ := sigma.At(i)
+		names[i] = string(a.(nfa.Letter))
+	}
+	sort.Strings(names)
+
+	letters := make([]nfa.Letter, len(names))
+	for i, a := range names {
+		letters[i] = nfa.Letter(a)
+	}
+	return letters
+}
+
+// the minimized dfa as nfa.
+// language equivalent nfas have isomorphic minimal dfas, which makes nfa.IsEqual a language comparison.
+func MinimalNfa(A nfa.Nfa) nfa.Nfa {
+	return Minimize(Determinize(A)).Nfa()
+}
@@ -0,0 +1,68 @@
+package dfa
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"github.com/hydroo/gomochex/regex"
+	"testing"
+)
+
+func TestMinimize(t *testing.T) {
+	A := nfa.NewNfa()
+	json.Unmarshal(secondToLastIsA, &A)
+
+	// already minimal, the unreachable sink comes last
+	s := []byte(`{"States":["0","1","2","3","4"],"Alphabet":["a","b"],"InitialState":"0","Transitions":{"0":{"a":"1","b":"0"},"1":{"a":"2","b":"3"},"2":{"a":"2","b":"3"},"3":{"a":"1","b":"0"},"4":{"a":"4","b":"4"}},"FinalStates":["2","3"],"SinkState":"4"}`)
+	if u, err := json.Marshal(Minimize(Determinize(A))); err != nil || bytes.Compare(s, u) != 0 {
+		t.Error("\nshould:", string(s), "\nis:    ", string(u))
+	}
+
+	// -> 0 -a-> 1 -a-> 2 -a-> 1, 1 and 2 final, b leads to the sink
+	B := nfa.NewNfa()
+	json.Unmarshal([]byte(`{"States":["0","1","2"],"Alphabet":["b","a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"1":{"a":["2"]},"2":{"a":["1"]}},"FinalStates":["1","2"]}`), &B)
+
+	u := []byte(`{"States":["0","1","2"],"Alphabet":["a","b"],"InitialState":"0","Transitions":{"0":{"a":"1","b":"2"},"1":{"a":"1","b":"2"},"2":{"a":"2","b":"2"}},"FinalStates":["1"],"SinkState":"2"}`)
+	M := Minimize(Determinize(B))
+	if v, err := json.Marshal(M); err != nil || bytes.Compare(u, v) != 0 {
+		t.Error("\nshould:", string(u), "\nis:    ", string(v))
+	}
+
+	compareLanguages(B, M, 5, t)
+}
+
+func TestMinimizeEquivalentExpressions(t *testing.T) {
+	type test struct {
+		e, f          string
+		shouldBeEqual bool
+	}
+
+	tests := []test{
+		test{"(a+b)", "(b+a)", true},
+		test{"((a+b).c)", "((a.c)+(b.c))", true},
+		test{"((a)*.(a)*)", "(a)*", true},
+		test{"((((a)*.b))*.(a)*)", "((a+b))*", true},
+		test{"(a.((b.a))*)", "(((a.b))*.a)", true},
+		test{"(a)*", "((a.a))*", false},
+		test{"((a+b).c)", "((a.c)+b)", false},
+	}
+
+	for k, x := range tests {
+		e, okE := regex.ExpressionFromString(x.e)
+		f, okF := regex.ExpressionFromString(x.f)
+		if okE != true || okF != true {
+			t.Error("case ", k, " could not be parsed")
+			continue
+		}
+
+		s, _ := json.Marshal(Minimize(Determinize(e.Nfa())))
+		u, _ := json.Marshal(Minimize(Determinize(f.Nfa())))
+		if (bytes.Compare(s, u) == 0) != x.shouldBeEqual {
+			t.Error("case ", k, "\n", x.e, string(s), "\n", x.f, string(u))
+		}
+
+		if MinimalNfa(e.Nfa()).IsEqual(MinimalNfa(f.Nfa())) != x.shouldBeEqual {
+			t.Error("case ", k, " IsEqual")
+		}
+	}
+}
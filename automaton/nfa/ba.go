@@ -0,0 +1,156 @@
+package nfa
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// reads the BA format used by RABIT, Reduce and GOAL:
+//
+//	[initial state]
+//	...
+//	letter,[p]->[q]
+//	...
+//	[final state]
+//	...
+//
+// state lines before the first transition are initial, the ones after it are final.
+// without transitions the first state line is initial and the others are final.
+// as in the other tools all states are final if no final state is given.
+// the alphabet consists of the letters of the transitions.
+func ReadBA(r io.Reader) (Nfa, error) {
+	A := NewNfa()
+
+	stateLines := make([][]State, 3) // before, between and after the transitions
+	part := 0
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan() == true; line += 1 {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+
+		if strings.Contains(s, "->") == true {
+			if part == 2 {
+				return nil, fmt.Errorf("line %d: transition after the final states", line)
+			}
+			part = 1
+
+			m := baTransition.FindStringSubmatch(s)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: expected letter,[p]->[q]", line)
+			}
+
+			a := Letter(m[1])
+			p, err := parseBAState(m[2], line)
+			if err != nil {
+				return nil, err
+			}
+			q, err := parseBAState(m[3], line)
+			if err != nil {
+				return nil, err
+			}
+
+			A.Alphabet().Add(a)
+			A.States().Add(p)
+			A.States().Add(q)
+			T := A.Transition(p, a).Copy().(StateSet)
+			T.Add(q)
+			A.SetTransition(p, a, T)
+		} else {
+			q, err := parseBAState(s, line)
+			if err != nil {
+				return nil, err
+			}
+			if part == 1 {
+				part = 2
+			}
+			A.States().Add(q)
+			stateLines[part] = append(stateLines[part], q)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	initialStates, finalStates := stateLines[0], stateLines[2]
+	if part == 0 && len(initialStates) > 0 {
+		initialStates, finalStates = stateLines[0][:1], stateLines[0][1:]
+	}
+
+	for _, q := range initialStates {
+		A.InitialStates().Add(q)
+	}
+	if len(finalStates) == 0 {
+		A.SetFinalStates(A.States().Copy().(StateSet))
+	}
+	for _, q := range finalStates {
+		A.FinalStates().Add(q)
+	}
+
+	return A, nil
+}
+
+// the letter is everything before the first comma that is followed by a bracket, the source
+// state ends at the first bracket before an arrow. so commas in letters and states are fine.
+var baTransition = regexp.MustCompile(`^(.+?)\s*,\s*(\[.*?\])\s*->\s*(\[.*\])$`)
+
+func parseBAState(s string, line int) (State, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return "", fmt.Errorf("line %d: expected a state in brackets, found %q", line, s)
+	}
+	return State(s[1 : len(s)-1]), nil
+}
+
+// writes A in the BA format, see ReadBA.
+// epsilon transitions are eliminated first. states without transitions that are
+// neither initial nor final and letters without transitions are not written.
+//
+// fails if ReadBA would read something else: automata without final states, and automata
+// without transitions but with several initial states, or with final but without initial states.
+func WriteBA(w io.Writer, A Nfa) error {
+	A = withoutEpsilons(A)
+
+	transitions := make([]string, 0)
+	for i := 0; i < A.States().Size(); i += 1 {
+		p, _ := A.States().At(i)
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
+			a, _ := A.Alphabet().At(j)
+			Q := A.Transition(p.(State), a.(Letter))
+			for k := 0; k < Q.Size(); k += 1 {
+				q, _ := Q.At(k)
+				transitions = append(transitions, fmt.Sprint(a, ",[", p, "]->[", q, "]"))
+			}
+		}
+	}
+
+	if A.FinalStates().Size() == 0 && (len(transitions) > 0 || A.InitialStates().Size() > 0) {
+		return errors.New("the BA format cannot express automata without final states")
+	} else if len(transitions) == 0 && A.InitialStates().Size() > 1 {
+		return errors.New("the BA format cannot express several initial states without transitions")
+	} else if len(transitions) == 0 && A.InitialStates().Size() == 0 && A.FinalStates().Size() > 0 {
+		return errors.New("the BA format cannot express final states without initial states and transitions")
+	}
+
+	bw := bufio.NewWriter(w)
+
+	for i := 0; i < A.InitialStates().Size(); i += 1 {
+		q, _ := A.InitialStates().At(i)
+		fmt.Fprint(bw, "[", q, "]\n")
+	}
+	for _, t := range transitions {
+		fmt.Fprint(bw, t, "\n")
+	}
+	for i := 0; i < A.FinalStates().Size(); i += 1 {
+		q, _ := A.FinalStates().At(i)
+		fmt.Fprint(bw, "[", q, "]\n")
+	}
+
+	return bw.Flush()
+}
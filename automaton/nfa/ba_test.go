@@ -0,0 +1,135 @@
+package nfa
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestReadBA(t *testing.T) {
+	type test struct {
+		ba       string
+		expected string
+	}
+
+	tests := []test{
+		// initial, transitions, final
+		test{"[0]\na,[0]->[0]\nb,[0]->[0]\n a , [0] -> [1] \n\n[1]\n",
+			`{"States":["0","1"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["0","1"],"b":["0"]}},"FinalStates":["1"]}`},
+		// no final states: all states are final
+		test{"[0]\na,[0]->[1]\n",
+			`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]}},"FinalStates":["0","1"]}`},
+		// several initial states
+		test{"[0]\n[1]\na,[0]->[1]\n[1]\n",
+			`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0","1"],"Transitions":{"0":{"a":["1"]}},"FinalStates":["1"]}`},
+		// no transitions
+		test{"[0]\n[1]\n",
+			`{"States":["0","1"],"Alphabet":[],"InitialStates":["0"],"Transitions":{},"FinalStates":["1"]}`},
+		// letters with commas and state names with brackets
+		test{"[[q]]\n,,[[q]]->[q,r]\n[q,r]\n",
+			`{"States":["[q]","q,r"],"Alphabet":[","],"InitialStates":["[q]"],"Transitions":{"[q]":{",":["q,r"]}},"FinalStates":["q,r"]}`},
+		// commas in letters and states, as in product automata
+		test{"[(0,0)]\na,b,[(0,0)]->[(1,1)]\n[(1,1)]\n",
+			`{"States":["(0,0)","(1,1)"],"Alphabet":["a,b"],"InitialStates":["(0,0)"],"Transitions":{"(0,0)":{"a,b":["(1,1)"]}},"FinalStates":["(1,1)"]}`},
+		test{"",
+			`{"States":[],"Alphabet":[],"InitialStates":[],"Transitions":{},"FinalStates":[]}`},
+	}
+
+	for k, x := range tests {
+		A, err := ReadBA(strings.NewReader(x.ba))
+		if err != nil {
+			t.Error("case", k, err)
+			continue
+		}
+
+		expected := NewNfa()
+		json.Unmarshal([]byte(x.expected), &expected)
+
+		if A.IsEqual(expected) == false {
+			t.Error("case", k, A)
+		}
+	}
+}
+
+func TestReadBAErrors(t *testing.T) {
+	tests := []string{
+		"[0]\na,[0]->[1]\n[1]\nb,[1]->[0]\n",
+		"[0]\n[0]->[1]\n",
+		"[0]\na,0->[1]\n",
+		"[0]\na,[0]->[1\n",
+		"0\n",
+	}
+
+	for k, ba := range tests {
+		if _, err := ReadBA(strings.NewReader(ba)); err == nil {
+			t.Error("case", k)
+		}
+	}
+}
+
+func TestWriteBA(t *testing.T) {
+	tests := []string{
+		`{"States":["0","1","2"],"Alphabet":["a","b"],"InitialStates":["0","1"],"Transitions":{"0":{"a":["0","2"],"b":["1"]},"1":{"a":["2"]}},"FinalStates":["2"]}`,
+		`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"1":{"a":["1"]}},"FinalStates":["0","1"]}`,
+		`{"States":["0"],"Alphabet":[],"InitialStates":["0"],"Transitions":{},"FinalStates":["0"]}`,
+		`{"States":[],"Alphabet":[],"InitialStates":[],"Transitions":{},"FinalStates":[]}`,
+	}
+
+	for k, x := range tests {
+		A := NewNfa()
+		json.Unmarshal([]byte(x), &A)
+
+		var b bytes.Buffer
+		if err := WriteBA(&b, A); err != nil {
+			t.Error("case", k, err)
+			continue
+		}
+
+		B, err := ReadBA(&b)
+		if err != nil {
+			t.Error("case", k, err)
+		} else if A.IsEqual(B) == false {
+			t.Error("case", k, B)
+		}
+	}
+
+	var b bytes.Buffer
+	A := NewNfa()
+	json.Unmarshal([]byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]}},"FinalStates":["1"]}`), &A)
+	WriteBA(&b, A)
+	if b.String() != "[0]\na,[0]->[1]\n[1]\n" {
+		t.Error(b.String())
+	}
+
+	// states of products contain commas
+	b.Reset()
+	P := Intersect(A, A)
+	if err := WriteBA(&b, P); err != nil {
+		t.Error(err)
+	} else if Q, err := ReadBA(&b); err != nil || Q.IsEqual(P) == false {
+		t.Error(Q, err)
+	}
+
+	// epsilon transitions are eliminated
+	b.Reset()
+	C := NewNfa()
+	json.Unmarshal(epsilonAStarB, &C)
+	if err := WriteBA(&b, C); err != nil {
+		t.Error(err)
+	} else if D, _ := ReadBA(&b); D.IsEqual(C.EliminateEpsilons().Trim()) == false {
+		t.Error(D)
+	}
+
+	// no final states
+	for _, x := range []string{
+		`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]}},"FinalStates":[]}`,
+		`{"States":["0","1"],"Alphabet":[],"InitialStates":["0","1"],"Transitions":{},"FinalStates":["1"]}`,
+	} {
+		E := NewNfa()
+		json.Unmarshal([]byte(x), &E)
+		if err := WriteBA(&b, E); err == nil {
+			t.Error(x)
+		}
+	}
+}
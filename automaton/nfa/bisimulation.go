@@ -0,0 +1,342 @@
+package nfa
+
+import (
+	"fmt"
+)
+
+// every initial state of A is bisimilar to an initial state of B and vice versa.
+// bisimilar automata accept the same language.
+func Bisimulation(A, B Nfa) bool {
+	C := Union(A, B)
+	blockOf := bisimulationPartition(C)
+
+	initialBlocks := func(k int, T Nfa) map[int]bool {
+		blocks := make(map[int]bool)
+		for i := 0; i < T.InitialStates().Size(); i += 1 {
+			q, _ := T.InitialStates().At(i)
+			blocks[blockOf[State(fmt.Sprint(k, q))]] = true
+		}
+		return blocks
+	}
+
+	blocksA := initialBlocks(0, A)
+	blocksB := initialBlocks(1, B)
+
+	if len(blocksA) != len(blocksB) {
+		return false
+	}
+	for b := range blocksA {
+		if blocksB[b] == false {
+			return false
+		}
+	}
+	return true
+}
+
+// merges bisimilar states
+// every class is represented by its first state in A.States()
+func QuotientByBisimulation(A Nfa) Nfa {
+	blockOf := bisimulationPartition(A)
+
+	representative := make(map[int]State)
+	for i := 0; i < A.States().Size(); i += 1 {
+		q, _ := A.States().At(i)
+		if _, ok := representative[blockOf[q.(State)]]; ok == false {
+			representative[blockOf[q.(State)]] = q.(State)
+		}
+	}
+
+	B := NewNfa()
+	B.SetAlphabet(A.Alphabet().Copy().(Alphabet))
+
+	translate := func(Q StateSet, Q_ StateSet) StateSet {
+		Q_ = Q_.Copy().(StateSet)
+		for i := 0; i < Q.Size(); i += 1 {
+			q, _ := Q.At(i)
+			Q_.Add(representative[blockOf[q.(State)]])
+		}
+		return Q_
+	}
+
+	for i := 0; i < A.States().Size(); i += 1 {
+		p_, _ := A.States().At(i)
+		p := p_.(State)
+		r := representative[blockOf[p]]
+
+		B.States().Add(r)
+		if A.InitialStates().Probe(p) == true {
+			B.InitialStates().Add(r)
+		}
+		if A.FinalStates().Probe(p) == true {
+			B.FinalStates().Add(r)
+		}
+
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
+			a, _ := A.Alphabet().At(j)
+			B.SetTransition(r, a.(Letter), translate(A.Transition(p, a.(Letter)), B.Transition(r, a.(Letter))))
+		}
+		B.SetEpsilonTransition(r, translate(A.EpsilonTransition(p), B.EpsilonTransition(r)))
+	}
+
+	return B
+}
+
+// the coarsest partition of the states of A that is stable with respect to all letters
+// and epsilon transitions, and separates final from non-final states.
+//
+// Paige and Tarjan's relational coarsest partition algorithm, O(m log n).
+// labels are removed by inserting a node (a,r) on every transition q --a--> r.
+// these nodes start in one block per label, the states start in one block for final
+// and one for non-final states.
+func bisimulationPartition(A Nfa) map[State]int {
+
+	// the graph
+
+	n := A.States().Size()
+	labels := A.Alphabet().Size() + 1 // the last one is epsilon
+
+	states := make([]State, n)
+	index := make(map[State]int)
+	for i := 0; i < n; i += 1 {
+		q, _ := A.States().At(i)
+		states[i] = q.(State)
+		index[q.(State)] = i
+	}
+
+	initialKey := make([]int, n) // label of the nodes (a,r), or labels + 0/1 for non-final/final states
+	successors := make([][]int, n)
+
+	labelNodes := make(map[[2]int]int)
+	labelNode := func(a, r int) int {
+		if v, ok := labelNodes[[2]int{a, r}]; ok == true {
+			return v
+		}
+		v := len(successors)
+		labelNodes[[2]int{a, r}] = v
+		successors = append(successors, []int{r})
+		initialKey = append(initialKey, a)
+		return v
+	}
+
+	for i, q := range states {
+		if A.FinalStates().Probe(q) == true {
+			initialKey[i] = labels + 1
+		} else {
+			initialKey[i] = labels
+		}
+
+		for a := 0; a < labels; a += 1 {
+			var R StateSet
+			if a < labels-1 {
+				l, _ := A.Alphabet().At(a)
+				R = A.Transition(q, l.(Letter))
+			} else {
+				R = A.EpsilonTransition(q)
+			}
+			for j := 0; j < R.Size(); j += 1 {
+				r, _ := R.At(j)
+				successors[i] = append(successors[i], labelNode(a, index[r.(State)]))
+			}
+		}
+	}
+
+	N := len(successors)
+
+	// edges
+	edgeSource := make([]int, 0)
+	predecessorEdges := make([][]int, N)
+	for x := 0; x < N; x += 1 {
+		for _, y := range successors[x] {
+			predecessorEdges[y] = append(predecessorEdges[y], len(edgeSource))
+			edgeSource = append(edgeSource, x)
+		}
+	}
+
+	// counts[edgeCount[e]] = |successors(x) ∩ S| for the edge e = x -> y, where S is the X block of y
+	counts := make([]int, N)
+	edgeCount := make([]int, len(edgeSource))
+	for x := 0; x < N; x += 1 {
+		counts[x] = len(successors[x])
+	}
+	for e, x := range edgeSource {
+		edgeCount[e] = x
+	}
+
+	// the partition Q: every block is a segment of elements
+
+	type qBlock struct {
+		start, end int
+		marked     int
+		xBlock     int
+		xPosition  int // position in xBlocks[xBlock]
+	}
+
+	elements := make([]int, N)
+	position := make([]int, N)
+	blockOf := make([]int, N)
+	qBlocks := make([]qBlock, 0)
+
+	// the partition X: every block is a union of Q blocks
+	xBlocks := make([][]int, 0)
+	compound := make([]int, 0)
+
+	addToX := func(b, x int) {
+		qBlocks[b].xBlock = x
+		qBlocks[b].xPosition = len(xBlocks[x])
+		xBlocks[x] = append(xBlocks[x], b)
+		if len(xBlocks[x]) == 2 {
+			compound = append(compound, x)
+		}
+	}
+
+	removeFromX := func(b int) {
+		x := qBlocks[b].xBlock
+		p := qBlocks[b].xPosition
+		last := xBlocks[x][len(xBlocks[x])-1]
+		xBlocks[x][p] = last
+		qBlocks[last].xPosition = p
+		xBlocks[x] = xBlocks[x][:len(xBlocks[x])-1]
+	}
+
+	// initial partition, refined with respect to the nodes with and without successors
+	{
+		groups := make(map[[2]int][]int)
+		keys := make([][2]int, 0)
+		for x := 0; x < N; x += 1 {
+			hasSuccessors := 0
+			if len(successors[x]) > 0 {
+				hasSuccessors = 1
+			}
+			k := [2]int{initialKey[x], hasSuccessors}
+			if _, ok := groups[k]; ok == false {
+				keys = append(keys, k)
+			}
+			groups[k] = append(groups[k], x)
+		}
+
+		xBlocks = append(xBlocks, make([]int, 0))
+		p := 0
+		for _, k := range keys {
+			b := len(qBlocks)
+			qBlocks = append(qBlocks, qBlock{p, p + len(groups[k]), 0, 0, 0})
+			for _, x := range groups[k] {
+				elements[p] = x
+				position[x] = p
+				blockOf[x] = b
+				p += 1
+			}
+			addToX(b, 0)
+		}
+	}
+
+	// splits every block with marked elements into its marked and unmarked part
+	marks := make([]int, 0)
+	mark := func(x int) {
+		b := blockOf[x]
+		B := &qBlocks[b]
+		p := B.start + B.marked
+		y := elements[p]
+		elements[p], elements[position[x]] = x, y
+		position[y] = position[x]
+		position[x] = p
+		B.marked += 1
+		if B.marked == 1 {
+			marks = append(marks, b)
+		}
+	}
+	split := func() {
+		for _, b := range marks {
+			B := &qBlocks[b]
+			if B.marked == B.end-B.start {
+				B.marked = 0
+				continue
+			}
+
+			c := len(qBlocks)
+			qBlocks = append(qBlocks, qBlock{B.start, B.start + B.marked, 0, 0, 0})
+			B = &qBlocks[b]
+			B.start += B.marked
+			B.marked = 0
+			for p := qBlocks[c].start; p < qBlocks[c].end; p += 1 {
+				blockOf[elements[p]] = c
+			}
+			addToX(c, B.xBlock)
+		}
+		marks = marks[:0]
+	}
+
+	countInB := make([]int, N)
+	edgeIntoB := make([]int, N)
+
+	for len(compound) > 0 {
+		S := compound[len(compound)-1]
+		compound = compound[:len(compound)-1]
+		if len(xBlocks[S]) < 2 {
+			continue
+		}
+
+		// B is at most half as large as S
+		b := xBlocks[S][0]
+		if c := xBlocks[S][1]; qBlocks[c].end-qBlocks[c].start < qBlocks[b].end-qBlocks[b].start {
+			b = c
+		}
+
+		removeFromX(b)
+		if len(xBlocks[S]) >= 2 {
+			compound = append(compound, S)
+		}
+		xBlocks = append(xBlocks, make([]int, 0))
+		addToX(b, len(xBlocks)-1)
+
+		B := make([]int, qBlocks[b].end-qBlocks[b].start)
+		copy(B, elements[qBlocks[b].start:qBlocks[b].end])
+
+		// pre(B) and the counts |successors(x) ∩ B|
+		preB := make([]int, 0)
+		for _, y := range B {
+			for _, e := range predecessorEdges[y] {
+				x := edgeSource[e]
+				if countInB[x] == 0 {
+					preB = append(preB, x)
+					edgeIntoB[x] = e
+				}
+				countInB[x] += 1
+			}
+		}
+
+		// split with respect to pre(B)
+		for _, x := range preB {
+			mark(x)
+		}
+		split()
+
+		// split with respect to pre(B) \ pre(S \ B)
+		for _, x := range preB {
+			if countInB[x] == counts[edgeCount[edgeIntoB[x]]] {
+				mark(x)
+			}
+		}
+		split()
+
+		// the old counts now belong to S \ B
+		for _, x := range preB {
+			counts[edgeCount[edgeIntoB[x]]] -= countInB[x]
+			counts = append(counts, countInB[x])
+			edgeIntoB[x] = len(counts) - 1
+		}
+		for _, y := range B {
+			for _, e := range predecessorEdges[y] {
+				edgeCount[e] = edgeIntoB[edgeSource[e]]
+			}
+		}
+		for _, x := range preB {
+			countInB[x] = 0
+		}
+	}
+
+	ret := make(map[State]int)
+	for i, q := range states {
+		ret[q] = blockOf[i]
+	}
+	return ret
+}
@@ -0,0 +1,174 @@
+package nfa
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBisimulation(t *testing.T) {
+	type test struct {
+		A, B              Nfa
+		shouldBeBisimilar bool
+	}
+
+	tests := []test{
+		test{Union(OneLetter("a"), OneLetter("b")), Union(OneLetter("b"), OneLetter("a")), true},
+		test{Concat(OneLetter("a"), OneLetter("b")), Union(Concat(OneLetter("a"), OneLetter("b")), Concat(OneLetter("a"), OneLetter("b"))), true},
+		test{KleeneStar(OneLetter("a")), KleeneStar(Concat(OneLetter("a"), KleeneStar(OneLetter("a")))), false},
+		// language equivalent, but the choice is made at different times
+		test{Concat(OneLetter("a"), Union(OneLetter("b"), OneLetter("c"))), Union(Concat(OneLetter("a"), OneLetter("b")), Concat(OneLetter("a"), OneLetter("c"))), false},
+		test{OneLetter("a"), OneLetter("b"), false},
+		test{OneLetter("a"), NewNfa(), false},
+	}
+
+	for k, x := range tests {
+		if Bisimulation(x.A, x.B) != x.shouldBeBisimilar {
+			t.Error("case ", k)
+		}
+		if Bisimulation(x.B, x.A) != x.shouldBeBisimilar {
+			t.Error("case ", k, " swapped")
+		}
+	}
+
+	// epsilon transitions are compared, too
+	A := NewNfa()
+	json.Unmarshal(epsilonAStarB, &A)
+	if Bisimulation(A, A.Copy()) != true || Bisimulation(A, A.EliminateEpsilons()) != false {
+		t.Error()
+	}
+}
+
+func TestQuotientByBisimulation(t *testing.T) {
+	// ((a.b)+(a.b))*
+	A := KleeneStar(Union(Concat(OneLetter("a"), OneLetter("b")), Concat(OneLetter("a"), OneLetter("b"))))
+	B := QuotientByBisimulation(A)
+
+	if equal, counterexample := Equivalent(A, B); equal != true {
+		t.Error(counterexample)
+	}
+	if Bisimulation(A, B) != true {
+		t.Error()
+	}
+	if C := QuotientByBisimulation(B); C.States().Size() != B.States().Size() {
+		t.Error(B, C)
+	}
+
+	// -> 0 -a-> 1 -a-> 2 -a-> 3 (final), with 1 -a-> 1' -a-> 3
+	s := []byte(`{"States":["0","1","2","1'","3"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1","1'"]},"1":{"a":["2"]},"1'":{"a":["2"]},"2":{"a":["3"]}},"FinalStates":["3"]}`)
+	u := []byte(`{"States":["0","1","2","3"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"1":{"a":["2"]},"2":{"a":["3"]}},"FinalStates":["3"]}`)
+	D := NewNfa()
+	json.Unmarshal(s, &D)
+	compareNfaToMarshaledNfa(QuotientByBisimulation(D), u, true, t, "")
+}
+
+// compares the partition to a naive fixpoint computation
+func TestBisimulationPartition(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	for k := 0; k < 50; k += 1 {
+		A := NewNfa()
+		A.Alphabet().Add(Letter("a"), Letter("b"))
+		n := 2 + rng.Intn(20)
+		for i := 0; i < n; i += 1 {
+			A.States().Add(State(fmt.Sprint(i)))
+			if rng.Intn(3) == 0 {
+				A.FinalStates().Add(State(fmt.Sprint(i)))
+			}
+		}
+		A.InitialStates().Add(State("0"))
+		for i := 0; i < 2*n; i += 1 {
+			q := State(fmt.Sprint(rng.Intn(n)))
+			r := State(fmt.Sprint(rng.Intn(n)))
+			if rng.Intn(5) == 0 {
+				A.SetEpsilonTransition(q, withState(A.EpsilonTransition(q), r))
+			} else {
+				a := Letter([]string{"a", "b"}[rng.Intn(2)])
+				A.SetTransition(q, a, withState(A.Transition(q, a), r))
+			}
+		}
+
+		if is, should := partitionString(A, bisimulationPartition(A)), partitionString(A, naiveBisimulationPartition(A)); is != should {
+			t.Error("case ", k, "\nshould:", should, "\nis:    ", is, "\n", A)
+		}
+	}
+}
+
+func withState(S StateSet, q State) StateSet {
+	S = S.Copy().(StateSet)
+	S.Add(q)
+	return S
+}
+
+func naiveBisimulationPartition(A Nfa) map[State]int {
+	blockOf := make(map[State]int)
+	for i := 0; i < A.States().Size(); i += 1 {
+		q, _ := A.States().At(i)
+		if A.FinalStates().Probe(q) == true {
+			blockOf[q.(State)] = 1
+		}
+	}
+
+	for {
+		signatures := make(map[string]int)
+		next := make(map[State]int)
+		for i := 0; i < A.States().Size(); i += 1 {
+			q, _ := A.States().At(i)
+			signature := fmt.Sprint(blockOf[q.(State)])
+			for j := 0; j <= A.Alphabet().Size(); j += 1 {
+				var R StateSet
+				if j < A.Alphabet().Size() {
+					a, _ := A.Alphabet().At(j)
+					R = A.Transition(q.(State), a.(Letter))
+				} else {
+					R = A.EpsilonTransition(q.(State))
+				}
+				blocks := make(map[int]bool)
+				for l := 0; l < R.Size(); l += 1 {
+					r, _ := R.At(l)
+					blocks[blockOf[r.(State)]] = true
+				}
+				sorted := make([]int, 0)
+				for b := range blocks {
+					sorted = append(sorted, b)
+				}
+				sort.Ints(sorted)
+				signature += fmt.Sprint(j, sorted)
+			}
+			if _, ok := signatures[signature]; ok == false {
+				signatures[signature] = len(signatures)
+			}
+			next[q.(State)] = signatures[signature]
+		}
+
+		if len(signatures) == len(partitionBlocks(blockOf)) {
+			return next
+		}
+		blockOf = next
+	}
+}
+
+func partitionBlocks(blockOf map[State]int) map[int]bool {
+	blocks := make(map[int]bool)
+	for _, b := range blockOf {
+		blocks[b] = true
+	}
+	return blocks
+}
+
+// the blocks as sorted lists of states
+func partitionString(A Nfa, blockOf map[State]int) string {
+	blocks := make(map[int][]string)
+	for q, b := range blockOf {
+		blocks[b] = append(blocks[b], string(q))
+	}
+	sorted := make([]string, 0)
+	for _, B := range blocks {
+		sort.Strings(B)
+		sorted = append(sorted, fmt.Sprint(B))
+	}
+	sort.Strings(sorted)
+	return fmt.Sprint(sorted)
+}
@@ -0,0 +1,401 @@
+package nfa
+
+import (
+	"fmt"
+	"sort"
+)
+
+// an isomorphic copy of A with the states renamed to "0", "1", ... in a canonical order,
+// and the letters and all sets in sorted order.
+// hence two automata are isomorphic iff the JSON of their canonical forms is equal.
+// states which are used but not declared in States are dropped, see Validate.
+func CanonicalForm(A Nfa) Nfa {
+	g := newLabeledGraph(A)
+	order, _ := g.canonicalLabeling()
+
+	position := make([]int, g.n)
+	for p, v := range order {
+		position[v] = p
+	}
+
+	name := func(p int) State {
+		return State(fmt.Sprint(p))
+	}
+
+	B := NewNfa()
+	for _, a := range g.letters {
+		B.Alphabet().Add(a)
+	}
+
+	for p, v := range order {
+		B.States().Add(name(p))
+		if A.InitialStates().Probe(g.states[v]) == true {
+			B.InitialStates().Add(name(p))
+		}
+	}
+	for p, v := range order {
+		if A.FinalStates().Probe(g.states[v]) == true {
+			B.FinalStates().Add(name(p))
+		}
+	}
+
+	for p, v := range order {
+		targets := make([][]int, len(g.letters)+1)
+		for _, e := range g.successors[v] {
+			targets[e[0]] = append(targets[e[0]], position[e[1]])
+		}
+		for l, T := range targets {
+			sort.Ints(T)
+			S := NewNfa().States()
+			for _, r := range T {
+				S.Add(name(r))
+			}
+			if l < len(g.letters) {
+				B.SetTransition(name(p), g.letters[l], S)
+			} else {
+				B.SetEpsilonTransition(name(p), S)
+			}
+		}
+	}
+
+	return B
+}
+
+// whether there is a bijection between the states that preserves initial and final states and transitions
+func isomorphic(A, B Nfa) bool {
+	if A.States().Size() != B.States().Size() || A.Alphabet().IsEqual(B.Alphabet()) == false {
+		return false
+	}
+
+	_, certificateA := newLabeledGraph(A).canonicalLabeling()
+	_, certificateB := newLabeledGraph(B).canonicalLabeling()
+
+	return compareCertificates(certificateA, certificateB) == 0
+}
+
+/*****************************************************************************/
+
+// the states of an Nfa as vertices 0, ..., n-1 in the order of A.States().
+// edges are labeled with the index of their letter in the sorted alphabet, epsilon transitions with len(letters).
+type labeledGraph struct {
+	n            int
+	states       []State
+	letters      []Letter
+	flags        []int      // 2*initial + final
+	successors   [][][2]int // (label, w) for every edge v -> w
+	predecessors [][][2]int // (label, v) for every edge v -> w
+}
+
+func newLabeledGraph(A Nfa) labeledGraph {
+	n := A.States().Size()
+	g := labeledGraph{n, make([]State, n), make([]Letter, 0), make([]int, n), make([][][2]int, n), make([][][2]int, n)}
+
+	index := make(map[State]int)
+	for i := 0; i < n; i += 1 {
+		q, _ := A.States().At(i)
+		g.states[i] = q.(State)
+		index[q.(State)] = i
+	}
+
+	letters := make([]string, A.Alphabet().Size())
+	for i := 0; i < A.Alphabet().Size(); i += 1 {
+		a, _ := A.Alphabet().At(i)
+		letters[i] = string(a.(Letter))
+	}
+	sort.Strings(letters)
+	for _, a := range letters {
+		g.letters = append(g.letters, Letter(a))
+	}
+
+	for v, q := range g.states {
+		if A.InitialStates().Probe(q) == true {
+			g.flags[v] += 2
+		}
+		if A.FinalStates().Probe(q) == true {
+			g.flags[v] += 1
+		}
+
+		for l := 0; l <= len(g.letters); l += 1 {
+			var R StateSet
+			if l < len(g.letters) {
+				R = A.Transition(q, g.letters[l])
+			} else {
+				R = A.EpsilonTransition(q)
+			}
+			for j := 0; j < R.Size(); j += 1 {
+				r, _ := R.At(j)
+				if w, ok := index[r.(State)]; ok == true {
+					g.successors[v] = append(g.successors[v], [2]int{l, w})
+					g.predecessors[w] = append(g.predecessors[w], [2]int{l, v})
+				}
+			}
+		}
+	}
+
+	return g
+}
+
+// color refinement: splits the color classes by the colors of the neighbors until nothing changes.
+// the new colors are 0, 1, ..., ordered by the old colors first. they only depend on the graph
+// and the old colors, not on the numbering of the vertices.
+func (g labeledGraph) refine(colors []int) []int {
+	colorCount := func(colors []int) int {
+		seen := make(map[int]bool)
+		for _, c := range colors {
+			seen[c] = true
+		}
+		return len(seen)
+	}
+
+	signature := func(colors []int, v int) []int {
+		neighbors := func(edges [][2]int) []int {
+			ret := make([]int, len(edges))
+			for k, e := range edges {
+				ret[k] = e[0]*g.n + colors[e[1]]
+			}
+			sort.Ints(ret)
+			return ret
+		}
+		ret := []int{colors[v]}
+		ret = append(ret, neighbors(g.successors[v])...)
+		ret = append(ret, -1)
+		ret = append(ret, neighbors(g.predecessors[v])...)
+		return ret
+	}
+
+	count := colorCount(colors)
+	for {
+		signatures := make([][]int, g.n)
+		vertices := make([]int, g.n)
+		for v := 0; v < g.n; v += 1 {
+			signatures[v] = signature(colors, v)
+			vertices[v] = v
+		}
+		sort.SliceStable(vertices, func(i, j int) bool {
+			return compareCertificates(signatures[vertices[i]], signatures[vertices[j]]) < 0
+		})
+
+		refined := make([]int, g.n)
+		c := 0
+		for k, v := range vertices {
+			if k > 0 && compareCertificates(signatures[vertices[k-1]], signatures[v]) != 0 {
+				c += 1
+			}
+			refined[v] = c
+		}
+
+		if c+1 == count {
+			return refined
+		}
+		colors = refined
+		count = c + 1
+	}
+}
+
+// the graph with vertex v renamed to position[v]
+func (g labeledGraph) certificate(position []int) []int {
+	order := make([]int, g.n)
+	for v, p := range position {
+		order[p] = v
+	}
+
+	ret := make([]int, 0)
+	for _, v := range order {
+		ret = append(ret, g.flags[v])
+		edges := make([]int, len(g.successors[v]))
+		for k, e := range g.successors[v] {
+			edges[k] = e[0]*g.n + position[e[1]]
+		}
+		sort.Ints(edges)
+		ret = append(ret, edges...)
+		ret = append(ret, -1)
+	}
+	return ret
+}
+
+// lexicographic, a proper prefix is smaller
+func compareCertificates(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i += 1 {
+		if a[i] < b[i] {
+			return -1
+		} else if a[i] > b[i] {
+			return 1
+		}
+	}
+	if len(a) < len(b) {
+		return -1
+	} else if len(a) > len(b) {
+		return 1
+	}
+	return 0
+}
+
+// individualization-refinement: the vertices of the first non-singleton color class are
+// individualized one after another and the colors are refined again, until every vertex
+// has its own color. the leaf with the smallest certificate is the canonical labeling.
+//
+// automorphisms are found as leaves with equal certificates. they prune children that are
+// in the same orbit as an already searched child, and subtrees that mirror the first or best leaf.
+//
+// returns the vertices in canonical order and the certificate.
+func (g labeledGraph) canonicalLabeling() ([]int, []int) {
+	var firstPath, bestPath []int
+	var firstPosition, bestPosition []int
+	var firstCertificate, bestCertificate []int
+	automorphisms := make([][]int, 0)
+
+	commonPrefix := func(a, b []int) int {
+		k := 0
+		for k < len(a) && k < len(b) && a[k] == b[k] {
+			k += 1
+		}
+		return k
+	}
+
+	// position -> vertex, composed with the inverse of another leaf
+	automorphism := func(position, other []int) []int {
+		order := make([]int, g.n)
+		for v, p := range position {
+			order[p] = v
+		}
+		gamma := make([]int, g.n)
+		for v, p := range other {
+			gamma[v] = order[p]
+		}
+		return gamma
+	}
+
+	// the orbits of the automorphisms which fix the given vertices
+	orbits := func(fixed []int) []int {
+		parent := make([]int, g.n)
+		for v := range parent {
+			parent[v] = v
+		}
+		var find func(int) int
+		find = func(v int) int {
+			for parent[v] != v {
+				parent[v] = parent[parent[v]]
+				v = parent[v]
+			}
+			return v
+		}
+
+		for _, gamma := range automorphisms {
+			fixes := true
+			for _, v := range fixed {
+				if gamma[v] != v {
+					fixes = false
+					break
+				}
+			}
+			if fixes == false {
+				continue
+			}
+			for v, w := range gamma {
+				if x, y := find(v), find(w); x != y {
+					parent[x] = y
+				}
+			}
+		}
+
+		for v := range parent {
+			parent[v] = find(v)
+		}
+		return parent
+	}
+
+	// returns the depth to jump back to, or -1
+	var search func([]int, []int) int
+	search = func(path []int, colors []int) int {
+		colors = g.refine(colors)
+
+		size := make(map[int]int)
+		for _, c := range colors {
+			size[c] += 1
+		}
+
+		if len(size) == g.n {
+			certificate := g.certificate(colors)
+
+			if firstCertificate == nil {
+				firstPath, bestPath = path, path
+				firstPosition, bestPosition = colors, colors
+				firstCertificate, bestCertificate = certificate, certificate
+				return -1
+			}
+
+			if compareCertificates(certificate, firstCertificate) == 0 {
+				automorphisms = append(automorphisms, automorphism(colors, firstPosition))
+				return commonPrefix(path, firstPath)
+			}
+
+			switch compareCertificates(certificate, bestCertificate) {
+			case -1:
+				bestPath, bestPosition, bestCertificate = path, colors, certificate
+			case 0:
+				automorphisms = append(automorphisms, automorphism(colors, bestPosition))
+				return commonPrefix(path, bestPath)
+			}
+			return -1
+		}
+
+		target := -1
+		for c := 0; c < len(size); c += 1 {
+			if size[c] > 1 {
+				target = c
+				break
+			}
+		}
+
+		searched := make([]int, 0)
+		for w := 0; w < g.n; w += 1 {
+			if colors[w] != target {
+				continue
+			}
+
+			orbit := orbits(path)
+			skip := false
+			for _, u := range searched {
+				if orbit[u] == orbit[w] {
+					skip = true
+					break
+				}
+			}
+			if skip == true {
+				continue
+			}
+			searched = append(searched, w)
+
+			// w comes first in its color class
+			individualized := make([]int, g.n)
+			for v, c := range colors {
+				individualized[v] = 2*c + 1
+			}
+			individualized[w] = 2 * target
+
+			childPath := make([]int, len(path)+1)
+			copy(childPath, path)
+			childPath[len(path)] = w
+
+			if jump := search(childPath, individualized); jump >= 0 && jump < len(path) {
+				return jump
+			}
+		}
+
+		return -1
+	}
+
+	initial := make([]int, g.n)
+	copy(initial, g.flags)
+	search(make([]int, 0), initial)
+
+	if bestPosition == nil {
+		return make([]int, 0), make([]int, 0)
+	}
+
+	order := make([]int, g.n)
+	for v, p := range bestPosition {
+		order[p] = v
+	}
+	return order, bestCertificate
+}
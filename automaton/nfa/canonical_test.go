@@ -0,0 +1,212 @@
+package nfa
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hydroo/gomochex/basic/set"
+	"math/rand"
+	"testing"
+)
+
+func TestCanonicalForm(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	sigma := set.NewSet(Letter("a"), Letter("b"))
+
+	for k := 0; k < 50; k += 1 {
+		A := Random(12+k%20, sigma, 1.5, 0.3, rng)
+		A.SetEpsilonTransition("1", set.NewSet(State("2")))
+		B := shuffled(A, rng)
+
+		s, _ := json.Marshal(CanonicalForm(A))
+		u, _ := json.Marshal(CanonicalForm(B))
+		if string(s) != string(u) {
+			t.Error("case", k, string(s), string(u))
+		}
+		if A.IsEqual(B) != true || B.IsEqual(A) != true || ToCompactNfa(B).IsEqual(A) != true {
+			t.Error("case", k)
+		}
+		if C := CanonicalForm(A); C.IsEqual(A) != true || C.Validate() != nil {
+			t.Error("case", k, C)
+		}
+	}
+
+	A := NewNfa()
+	json.Unmarshal(epsilonAStarB, &A)
+	s, _ := json.Marshal(CanonicalForm(A))
+	if string(s) != `{"States":["0","1","2","3"],"Alphabet":["a","b"],"InitialStates":["3"],"Transitions":{"0":{"a":["0"]},"1":{"b":["2"]}},"EpsilonTransitions":{"0":["1"],"3":["0"]},"FinalStates":["2"]}` {
+		t.Error(string(s))
+	}
+}
+
+// automata whose color refinement does not distinguish the states
+func TestCanonicalFormSymmetric(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	isolated := NewNfa()
+	cycle := NewNfa()
+	cycle.Alphabet().Add(Letter("a"))
+	for i := 0; i < 16; i += 1 {
+		isolated.States().Add(State(fmt.Sprint(i)))
+		cycle.States().Add(State(fmt.Sprint(i)))
+		cycle.SetTransition(State(fmt.Sprint(i)), "a", set.NewSet(State(fmt.Sprint((i+1)%16))))
+	}
+
+	// two cycles of length 8
+	cycles := cycle.Copy()
+	cycles.SetTransition("7", "a", set.NewSet(State("0")))
+	cycles.SetTransition("15", "a", set.NewSet(State("8")))
+
+	// six copies of a small automaton
+	copies := NewNfa()
+	for i := 0; i < 6; i += 1 {
+		copies = Union(copies, KleeneStar(Concat(OneLetter("a"), Union(OneLetter("b"), OneLetter("c")))))
+	}
+
+	for k, A := range []Nfa{isolated, cycle, cycles, copies} {
+		B := shuffled(A, rng)
+		if A.IsEqual(B) != true {
+			t.Error("case", k)
+		}
+	}
+
+	if cycle.IsEqual(cycles) != false {
+		t.Error()
+	}
+}
+
+func TestIsEqualAgainstBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	sigma := set.NewSet(Letter("a"))
+
+	isomorphic, notIsomorphic := 0, 0
+	for k := 0; k < 300; k += 1 {
+		A := Random(4, sigma, 1.5, 0.5, rng)
+		B := Random(4, sigma, 1.5, 0.5, rng)
+		if k%3 == 0 {
+			B = shuffled(A, rng)
+		} else if k%3 == 1 {
+			// possibly isomorphic
+			B = shuffled(A, rng)
+			if B.FinalStates().Probe(State("s0")) == true {
+				B.FinalStates().Remove(State("s0"))
+			} else {
+				B.FinalStates().Add(State("s0"))
+			}
+		}
+
+		expected := bruteForceIsomorphic(A, B)
+		if expected == true {
+			isomorphic += 1
+		} else {
+			notIsomorphic += 1
+		}
+		if A.IsEqual(B) != expected {
+			t.Error("case", k, A, B)
+		}
+	}
+
+	if isomorphic < 100 || notIsomorphic < 100 {
+		t.Error(isomorphic, notIsomorphic)
+	}
+}
+
+// A with the states renamed and declared in a random order
+func shuffled(A Nfa, rng *rand.Rand) Nfa {
+	n := A.States().Size()
+	permutation := rng.Perm(n)
+
+	name := make(map[State]State)
+	for i := 0; i < n; i += 1 {
+		q, _ := A.States().At(i)
+		name[q.(State)] = State(fmt.Sprint("s", permutation[i]))
+	}
+
+	translate := func(S StateSet) StateSet {
+		T := set.NewSet()
+		for i := 0; i < S.Size(); i += 1 {
+			q, _ := S.At(i)
+			T.Add(name[q.(State)])
+		}
+		return T
+	}
+
+	B := NewNfa()
+	B.SetAlphabet(A.Alphabet().Copy().(Alphabet))
+	for _, i := range rng.Perm(n) {
+		q_, _ := A.States().At(i)
+		q := q_.(State)
+
+		B.States().Add(name[q])
+		if A.InitialStates().Probe(q) == true {
+			B.InitialStates().Add(name[q])
+		}
+		if A.FinalStates().Probe(q) == true {
+			B.FinalStates().Add(name[q])
+		}
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
+			a, _ := A.Alphabet().At(j)
+			B.SetTransition(name[q], a.(Letter), translate(A.Transition(q, a.(Letter))))
+		}
+		B.SetEpsilonTransition(name[q], translate(A.EpsilonTransition(q)))
+	}
+	return B
+}
+
+func bruteForceIsomorphic(A, B Nfa) bool {
+	n := A.States().Size()
+	if n != B.States().Size() || A.Alphabet().IsEqual(B.Alphabet()) == false {
+		return false
+	}
+
+	image := make([]int, n)
+	used := make([]bool, n)
+
+	state := func(S StateSet, i int) State {
+		q, _ := S.At(i)
+		return q.(State)
+	}
+
+	matches := func() bool {
+		translate := func(S StateSet) StateSet {
+			T := set.NewSet()
+			for i := 0; i < n; i += 1 {
+				if S.Probe(state(A.States(), i)) == true {
+					T.Add(state(B.States(), image[i]))
+				}
+			}
+			return T
+		}
+
+		if translate(A.InitialStates()).IsEqual(B.InitialStates()) == false || translate(A.FinalStates()).IsEqual(B.FinalStates()) == false {
+			return false
+		}
+		for i := 0; i < n; i += 1 {
+			for j := 0; j < A.Alphabet().Size(); j += 1 {
+				a, _ := A.Alphabet().At(j)
+				if translate(A.Transition(state(A.States(), i), a.(Letter))).IsEqual(B.Transition(state(B.States(), image[i]), a.(Letter))) == false {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	var extend func(int) bool
+	extend = func(i int) bool {
+		if i == n {
+			return matches()
+		}
+		for j := 0; j < n; j += 1 {
+			if used[j] == false {
+				used[j], image[i] = true, j
+				if extend(i+1) == true {
+					return true
+				}
+				used[j] = false
+			}
+		}
+		return false
+	}
+
+	return extend(0)
+}
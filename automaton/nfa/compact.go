@@ -0,0 +1,781 @@
+package nfa
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hydroo/gomochex/basic/bitset"
+	"github.com/hydroo/gomochex/basic/set"
+	"math/bits"
+)
+
+// an empty Nfa which numbers its states and letters densely and stores sets of states as bitsets.
+//
+// like with NewNfa the sets returned by the getters are part of the automaton, changing them changes it.
+// copies of these sets are ordinary sets though. the setters copy their arguments.
+//
+// determinization, Trim, IsEmpty and the simulations work on the bitsets directly,
+// the other algorithms go through the Nfa interface.
+func NewCompactNfa() Nfa {
+	return &compactNfa{newNumbering(), newNumbering(), new(bitset.BitSet), new(bitset.BitSet), new(bitset.BitSet), new(bitset.BitSet), make([][]*bitset.BitSet, 0), make([]*bitset.BitSet, 0)}
+}
+
+// converts A losslessly, the order of the states and letters is kept
+func ToCompactNfa(A Nfa) Nfa {
+	B := NewCompactNfa()
+	copyNfa(A, B)
+	return B
+}
+
+// converts A losslessly into the implementation returned by NewNfa, the order of the states and letters is kept
+func ToSimpleNfa(A Nfa) Nfa {
+	if B, ok := A.(*simpleNfa); ok == true {
+		return B
+	}
+	B := NewNfa()
+	copyNfa(A, B)
+	return B
+}
+
+func copyNfa(A, B Nfa) {
+	B.SetStates(A.States().Copy().(StateSet))
+	B.SetAlphabet(A.Alphabet().Copy().(Alphabet))
+	B.SetInitialStates(A.InitialStates().Copy().(StateSet))
+	B.SetFinalStates(A.FinalStates().Copy().(StateSet))
+
+	// transitions may use undeclared states or letters, which Validate reports
+	states := set.Join(A.States(), A.InitialStates())
+	letters := A.Alphabet()
+	if C, ok := A.(*simpleNfa); ok == true {
+		letters = letters.Copy().(Alphabet)
+		for q, m := range C.transitions {
+			states.Add(q)
+			for a := range m {
+				letters.Add(a)
+			}
+		}
+		for q := range C.epsilonTransitions {
+			states.Add(q)
+		}
+	} else if C, ok := A.(*compactNfa); ok == true {
+		states = C.states.set()
+		letters = C.letters.set()
+	}
+
+	for i := 0; i < states.Size(); i += 1 {
+		q, _ := states.At(i)
+		for j := 0; j < letters.Size(); j += 1 {
+			a, _ := letters.At(j)
+			if Q := A.Transition(q.(State), a.(Letter)); Q.Size() > 0 {
+				B.SetTransition(q.(State), a.(Letter), Q.Copy().(StateSet))
+			}
+		}
+		if Q := A.EpsilonTransition(q.(State)); Q.Size() > 0 {
+			B.SetEpsilonTransition(q.(State), Q.Copy().(StateSet))
+		}
+	}
+}
+
+/*****************************************************************************/
+
+// assigns 0, 1, ... to states or letters, in the order they are seen first
+type numbering struct {
+	elements []set.Element
+	index    map[set.Element]int
+}
+
+func newNumbering() *numbering {
+	return &numbering{make([]set.Element, 0), make(map[set.Element]int)}
+}
+
+func (n *numbering) number(e set.Element) int {
+	if i, ok := n.index[e]; ok == true {
+		return i
+	}
+	n.index[e] = len(n.elements)
+	n.elements = append(n.elements, e)
+	return len(n.elements) - 1
+}
+
+func (n *numbering) lookup(e set.Element) (int, bool) {
+	i, ok := n.index[e]
+	return i, ok
+}
+
+func (n *numbering) copy() *numbering {
+	m := &numbering{make([]set.Element, len(n.elements)), make(map[set.Element]int)}
+	copy(m.elements, n.elements)
+	for e, i := range n.index {
+		m.index[e] = i
+	}
+	return m
+}
+
+// all numbered elements
+func (n *numbering) set() set.Set {
+	return set.NewSet(n.elements...)
+}
+
+/*****************************************************************************/
+
+// a set.Set view of a bitset, the bits are numbers of a numbering
+type compactSet struct {
+	numbering *numbering
+	members   *bitset.BitSet
+}
+
+func (S *compactSet) Add(elements ...set.Element) {
+	for _, e := range elements {
+		S.members.Add(bitset.BitPosition(S.numbering.number(e)))
+	}
+}
+
+// the elements are ordered by their numbers
+func (S compactSet) At(index int) (set.Element, bool) {
+	if index < 0 {
+		return nil, false
+	}
+	for w, v := range *S.members {
+		if c := bits.OnesCount64(v); index >= c {
+			index -= c
+			continue
+		}
+		for ; index > 0; index -= 1 {
+			v &= v - 1
+		}
+		return S.numbering.elements[w*64+bits.TrailingZeros64(v)], true
+	}
+	return nil, false
+}
+
+func (S *compactSet) Clear() {
+	*S.members = bitset.NewBitSet()
+}
+
+// an ordinary set, so that elements can be added while iterating over it with At
+func (S compactSet) Copy() set.Copier {
+	return set.NewSet(S.elements()...)
+}
+
+func (S compactSet) IsEqual(e set.Element) bool {
+	T, ok := e.(set.Set)
+	if ok == false || S.Size() != T.Size() {
+		return false
+	}
+	for i := 0; i < T.Size(); i += 1 {
+		t, _ := T.At(i)
+		if S.Probe(t) == false {
+			return false
+		}
+	}
+	return true
+}
+
+func (S compactSet) New() set.Newer {
+	return set.NewSet()
+}
+
+func (S compactSet) Probe(e set.Element) bool {
+	i, ok := S.numbering.lookup(e)
+	return ok == true && S.members.Probe(bitset.BitPosition(i))
+}
+
+func (S *compactSet) Remove(elements ...set.Element) {
+	for _, e := range elements {
+		if i, ok := S.numbering.lookup(e); ok == true {
+			S.members.Remove(bitset.BitPosition(i))
+		}
+	}
+}
+
+func (S compactSet) Size() int {
+	size := 0
+	for _, v := range *S.members {
+		size += bits.OnesCount64(v)
+	}
+	return size
+}
+
+func (S compactSet) String() string {
+	return fmt.Sprintf("%v", S.elements())
+}
+
+func (S compactSet) elements() []set.Element {
+	ret := make([]set.Element, 0)
+	forEachBit(*S.members, func(i int) {
+		ret = append(ret, S.numbering.elements[i])
+	})
+	return ret
+}
+
+func forEachBit(S bitset.BitSet, f func(int)) {
+	for w, v := range S {
+		for v != 0 {
+			f(w*64 + bits.TrailingZeros64(v))
+			v &= v - 1
+		}
+	}
+}
+
+/*****************************************************************************/
+
+type compactNfa struct {
+	states  *numbering
+	letters *numbering
+
+	stateSet      *bitset.BitSet
+	alphabet      *bitset.BitSet
+	initialStates *bitset.BitSet
+	finalStates   *bitset.BitSet
+
+	successors        [][]*bitset.BitSet // [state][letter], nil if there is no transition
+	epsilonSuccessors []*bitset.BitSet   // [state]
+}
+
+func (A *compactNfa) stateView(S *bitset.BitSet) StateSet {
+	return &compactSet{A.states, S}
+}
+
+// the bits of S, numbering new elements
+func bitsOf(S set.Set, n *numbering) *bitset.BitSet {
+	b := bitset.NewBitSet()
+	for i := 0; i < S.Size(); i += 1 {
+		e, _ := S.At(i)
+		b.Add(bitset.BitPosition(n.number(e)))
+	}
+	return &b
+}
+
+func (A *compactNfa) Alphabet() Alphabet {
+	return &compactSet{A.letters, A.alphabet}
+}
+
+func (A *compactNfa) SetAlphabet(sigma Alphabet) {
+	A.alphabet = bitsOf(sigma, A.letters)
+}
+
+func (A *compactNfa) InitialStates() StateSet {
+	return A.stateView(A.initialStates)
+}
+
+func (A *compactNfa) SetInitialStates(S StateSet) {
+	A.initialStates = bitsOf(S, A.states)
+}
+
+func (A *compactNfa) FinalStates() StateSet {
+	return A.stateView(A.finalStates)
+}
+
+func (A *compactNfa) SetFinalStates(F StateSet) {
+	A.finalStates = bitsOf(F, A.states)
+}
+
+func (A *compactNfa) States() StateSet {
+	return A.stateView(A.stateSet)
+}
+
+func (A *compactNfa) SetStates(S StateSet) {
+	A.stateSet = bitsOf(S, A.states)
+}
+
+// the successors of the q-th state with the a-th letter, allocated if necessary
+func (A *compactNfa) successor(q, a int) *bitset.BitSet {
+	for len(A.successors) <= q {
+		A.successors = append(A.successors, make([]*bitset.BitSet, 0))
+	}
+	for len(A.successors[q]) <= a {
+		A.successors[q] = append(A.successors[q], nil)
+	}
+	if A.successors[q][a] == nil {
+		A.successors[q][a] = new(bitset.BitSet)
+	}
+	return A.successors[q][a]
+}
+
+func (A *compactNfa) epsilonSuccessor(q int) *bitset.BitSet {
+	for len(A.epsilonSuccessors) <= q {
+		A.epsilonSuccessors = append(A.epsilonSuccessors, nil)
+	}
+	if A.epsilonSuccessors[q] == nil {
+		A.epsilonSuccessors[q] = new(bitset.BitSet)
+	}
+	return A.epsilonSuccessors[q]
+}
+
+// like with NewNfa, the returned set is not part of the automaton if there is no transition
+func (A *compactNfa) Transition(s State, l Letter) StateSet {
+	q, ok1 := A.states.lookup(s)
+	a, ok2 := A.letters.lookup(l)
+	if ok1 == false || ok2 == false || q >= len(A.successors) || a >= len(A.successors[q]) || A.successors[q][a] == nil {
+		return set.NewSet()
+	}
+	return A.stateView(A.successors[q][a])
+}
+
+func (A *compactNfa) SetTransition(s State, l Letter, S StateSet) {
+	q := A.states.number(s)
+	a := A.letters.number(l)
+	*A.successor(q, a) = *bitsOf(S, A.states)
+}
+
+func (A *compactNfa) SetTransitionFunction(delta func(State, Letter) StateSet) {
+	A.successors = make([][]*bitset.BitSet, 0)
+
+	forEachBit(*A.stateSet, func(q int) {
+		forEachBit(*A.alphabet, func(a int) {
+			Q := delta(A.states.elements[q].(State), A.letters.elements[a].(Letter))
+			if Q.Size() > 0 {
+				*A.successor(q, a) = *bitsOf(Q, A.states)
+			}
+		})
+	})
+}
+
+// like with NewNfa, the returned set is not part of the automaton if there are no epsilon transitions
+func (A *compactNfa) EpsilonTransition(s State) StateSet {
+	q, ok := A.states.lookup(s)
+	if ok == false || q >= len(A.epsilonSuccessors) || A.epsilonSuccessors[q] == nil {
+		return set.NewSet()
+	}
+	return A.stateView(A.epsilonSuccessors[q])
+}
+
+func (A *compactNfa) SetEpsilonTransition(s State, S StateSet) {
+	*A.epsilonSuccessor(A.states.number(s)) = *bitsOf(S, A.states)
+}
+
+func (A *compactNfa) EpsilonClosure(S StateSet) StateSet {
+	closure := A.epsilonClosure(*bitsOf(S, A.states))
+	return A.stateView(&closure).Copy().(StateSet)
+}
+
+func (A *compactNfa) epsilonClosure(S bitset.BitSet) bitset.BitSet {
+	closure := make(bitset.BitSet, len(S))
+	copy(closure, S)
+
+	queue := make([]int, 0)
+	forEachBit(closure, func(q int) {
+		queue = append(queue, q)
+	})
+
+	for len(queue) > 0 {
+		q := queue[0]
+		queue = queue[1:]
+		if q >= len(A.epsilonSuccessors) || A.epsilonSuccessors[q] == nil {
+			continue
+		}
+		forEachBit(*A.epsilonSuccessors[q], func(r int) {
+			if closure.Probe(bitset.BitPosition(r)) == false {
+				closure.Add(bitset.BitPosition(r))
+				queue = append(queue, r)
+			}
+		})
+	}
+
+	return closure
+}
+
+func (A *compactNfa) EliminateEpsilons() Nfa {
+	return ToCompactNfa(eliminateEpsilons(A))
+}
+
+func (A *compactNfa) Accepts(w []Letter) bool {
+	run, err := A.AcceptingRun(w)
+	return err == nil && run != nil
+}
+
+// returns nil if w is not accepted
+func (A *compactNfa) AcceptingRun(w []Letter) ([]State, error) {
+	return acceptingRun(A, w)
+}
+
+// the alphabet is kept, even if some letters are not used anymore
+func (A *compactNfa) Trim() Nfa {
+	n := len(A.states.elements)
+
+	predecessors := make([][]int, n)
+	A.forEachSuccessor(func(q, r int) {
+		predecessors[r] = append(predecessors[r], q)
+	})
+
+	reachable := A.search(*A.initialStates, func(q int, visit func(int)) {
+		if q < len(A.successors) {
+			for _, S := range A.successors[q] {
+				if S != nil {
+					forEachBit(*S, visit)
+				}
+			}
+		}
+		if q < len(A.epsilonSuccessors) && A.epsilonSuccessors[q] != nil {
+			forEachBit(*A.epsilonSuccessors[q], visit)
+		}
+	})
+	coreachable := A.search(bitset.Intersect(reachable, *A.finalStates), func(q int, visit func(int)) {
+		for _, p := range predecessors[q] {
+			visit(p)
+		}
+	})
+	useful := bitset.Intersect(reachable, coreachable)
+
+	restrict := func(S *bitset.BitSet) *bitset.BitSet {
+		if S == nil {
+			return nil
+		}
+		T := bitset.Intersect(*S, useful)
+		if T.Size() == 0 {
+			return nil
+		}
+		return &T
+	}
+
+	initialStates := bitset.Intersect(*A.initialStates, useful)
+	finalStates := bitset.Intersect(*A.finalStates, useful)
+	B := &compactNfa{A.states.copy(), A.letters.copy(), &useful, copyBits(A.alphabet), &initialStates, &finalStates, make([][]*bitset.BitSet, len(A.successors)), make([]*bitset.BitSet, len(A.epsilonSuccessors))}
+	forEachBit(useful, func(q int) {
+		if q < len(A.successors) {
+			B.successors[q] = make([]*bitset.BitSet, len(A.successors[q]))
+			for a, S := range A.successors[q] {
+				B.successors[q][a] = restrict(S)
+			}
+		}
+		if q < len(A.epsilonSuccessors) {
+			B.epsilonSuccessors[q] = restrict(A.epsilonSuccessors[q])
+		}
+	})
+
+	return B
+}
+
+// calls f for every transition from the q-th to the r-th state, epsilon transitions included
+func (A *compactNfa) forEachSuccessor(f func(q, r int)) {
+	for q, m := range A.successors {
+		for _, S := range m {
+			if S != nil {
+				forEachBit(*S, func(r int) { f(q, r) })
+			}
+		}
+	}
+	for q, S := range A.epsilonSuccessors {
+		if S != nil {
+			forEachBit(*S, func(r int) { f(q, r) })
+		}
+	}
+}
+
+// the states found by a depth first search from start, next visits the neighbours of a state
+func (A *compactNfa) search(start bitset.BitSet, next func(int, func(int))) bitset.BitSet {
+	visited := bitset.NewBitSet()
+	stack := make([]int, 0)
+
+	visit := func(q int) {
+		if visited.Probe(bitset.BitPosition(q)) == false {
+			visited.Add(bitset.BitPosition(q))
+			stack = append(stack, q)
+		}
+	}
+
+	forEachBit(start, visit)
+	for len(stack) > 0 {
+		q := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		next(q, visit)
+	}
+
+	return visited
+}
+
+func copyBits(S *bitset.BitSet) *bitset.BitSet {
+	if S == nil {
+		return nil
+	}
+	T := make(bitset.BitSet, len(*S))
+	copy(T, *S)
+	return &T
+}
+
+func (A *compactNfa) Copy() Nfa {
+	B := &compactNfa{A.states.copy(), A.letters.copy(), copyBits(A.stateSet), copyBits(A.alphabet), copyBits(A.initialStates), copyBits(A.finalStates), make([][]*bitset.BitSet, len(A.successors)), make([]*bitset.BitSet, len(A.epsilonSuccessors))}
+	for q, m := range A.successors {
+		B.successors[q] = make([]*bitset.BitSet, len(m))
+		for a, S := range m {
+			B.successors[q][a] = copyBits(S)
+		}
+	}
+	for q, S := range A.epsilonSuccessors {
+		B.epsilonSuccessors[q] = copyBits(S)
+	}
+
+	return B
+}
+
+func (A *compactNfa) Validate() error {
+	return ToSimpleNfa(A).Validate()
+}
+
+func (A *compactNfa) String() string {
+	return ToSimpleNfa(A).String()
+}
+
+func (A *compactNfa) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ToSimpleNfa(A))
+}
+
+// A is only changed if b is valid
+func (A *compactNfa) UnmarshalJSON(b []byte) error {
+	B, err := unmarshal(b, false)
+	if err != nil {
+		return err
+	}
+	*A = *ToCompactNfa(B).(*compactNfa)
+	return nil
+}
+
+func (A *compactNfa) IsEqual(B Nfa) bool {
+	return isomorphic(A, B)
+}
+
+// subset construction on bitsets, see determinize
+func (A *compactNfa) determinize(sigma Alphabet) Nfa {
+	B := NewCompactNfa()
+	B.SetAlphabet(sigma.Copy().(Alphabet))
+
+	letters := make([]int, 0)
+	for i := 0; i < sigma.Size(); i += 1 {
+		a, _ := sigma.At(i)
+		if j, ok := A.letters.lookup(a); ok == true {
+			letters = append(letters, j)
+		} else {
+			letters = append(letters, -1)
+		}
+	}
+
+	names := make(map[string]State)
+	queue := make([]bitset.BitSet, 0)
+
+	var add func(bitset.BitSet) State
+	add = func(S bitset.BitSet) State {
+		k := bitsKey(S)
+		if q, ok := names[k]; ok == true {
+			return q
+		}
+
+		q := State(fmt.Sprint(len(names)))
+		names[k] = q
+
+		B.States().Add(q)
+		for i := 0; i < len(S) && i < len(*A.finalStates); i += 1 {
+			if S[i]&(*A.finalStates)[i] != 0 {
+				B.FinalStates().Add(q)
+				break
+			}
+		}
+
+		queue = append(queue, S)
+		return q
+	}
+
+	var processQueue func()
+	processQueue = func() {
+		for len(queue) > 0 {
+			S := queue[0]
+			queue = queue[1:]
+			q := add(S)
+
+			for i, a := range letters {
+				l, _ := sigma.At(i)
+
+				T := bitset.NewBitSet()
+				if a >= 0 {
+					forEachBit(S, func(s int) {
+						if s < len(A.successors) && a < len(A.successors[s]) && A.successors[s][a] != nil {
+							T = bitset.Join(T, *A.successors[s][a])
+						}
+					})
+				}
+
+				B.SetTransition(q, l.(Letter), set.NewSet(add(A.epsilonClosure(T))))
+			}
+		}
+	}
+
+	B.InitialStates().Add(add(A.epsilonClosure(*A.initialStates)))
+	processQueue()
+
+	add(bitset.NewBitSet())
+	processQueue()
+
+	return B
+}
+
+// IsEmpty on bitsets, see there
+func (A *compactNfa) isEmpty() (bool, []Letter) {
+	n := len(A.states.elements)
+
+	// how a state was reached with the shortest word known so far, letter -1 stands for epsilon
+	predecessor := make([]int, n)
+	letter := make([]int, n)
+	length := make([]int, n)
+	for q := range length {
+		length[q] = -1
+	}
+	visited := bitset.NewBitSet()
+
+	// the deque of the 0-1 BFS is a stack for the front, and a queue with a head index for the back
+	front := make([]int, 0)
+	back := make([]int, 0)
+	head := 0
+
+	relax := func(q, p, a, l int) {
+		if length[q] >= 0 && length[q] <= l {
+			return
+		}
+		predecessor[q], letter[q], length[q] = p, a, l
+		if a < 0 {
+			front = append(front, q)
+		} else {
+			back = append(back, q)
+		}
+	}
+
+	forEachBit(*A.initialStates, func(q int) {
+		relax(q, -1, -1, 0)
+	})
+
+	for len(front) > 0 || head < len(back) {
+		var q int
+		if len(front) > 0 {
+			q = front[len(front)-1]
+			front = front[:len(front)-1]
+		} else {
+			q = back[head]
+			head += 1
+		}
+
+		if visited.Probe(bitset.BitPosition(q)) == true {
+			continue
+		}
+		visited.Add(bitset.BitPosition(q))
+
+		if A.finalStates.Probe(bitset.BitPosition(q)) == true {
+			word := make([]Letter, length[q])
+			for p := q; predecessor[p] >= 0; p = predecessor[p] {
+				if letter[p] >= 0 {
+					word[length[p]-1] = A.letters.elements[letter[p]].(Letter)
+				}
+			}
+			return false, word
+		}
+
+		if q < len(A.epsilonSuccessors) && A.epsilonSuccessors[q] != nil {
+			forEachBit(*A.epsilonSuccessors[q], func(r int) {
+				relax(r, q, -1, length[q])
+			})
+		}
+
+		forEachBit(*A.alphabet, func(a int) {
+			if q < len(A.successors) && a < len(A.successors[q]) && A.successors[q][a] != nil {
+				forEachBit(*A.successors[q][a], func(r int) {
+					relax(r, q, a, length[q]+1)
+				})
+			}
+		})
+	}
+
+	return true, nil
+}
+
+// simulation on bitsets, see simulation.
+// successors[q][a] are the states reached from the q-th state with the a-th letter, forward or backward.
+// in the beginning p is simulated by q if q ∈ S or p ∉ S.
+func (A *compactNfa) simulation(successors [][]*bitset.BitSet, S bitset.BitSet) simulationRelation {
+	step := func(q, a int) bitset.BitSet {
+		if q < len(successors) && a < len(successors[q]) && successors[q][a] != nil {
+			return *successors[q][a]
+		}
+		return nil
+	}
+
+	// simulatedBy[q] are the states which simulate the q-th state
+	simulatedBy := make([]bitset.BitSet, len(A.states.elements))
+	forEachBit(*A.stateSet, func(q int) {
+		if S.Probe(bitset.BitPosition(q)) == true {
+			simulatedBy[q] = bitset.Intersect(S, *A.stateSet)
+		} else {
+			simulatedBy[q] = *copyBits(A.stateSet)
+		}
+	})
+
+	for changed := true; changed == true; {
+		changed = false
+
+		forEachBit(*A.stateSet, func(p int) {
+			forEachBit(*copyBits(&simulatedBy[p]), func(q int) {
+				answered := true
+				forEachBit(*A.alphabet, func(a int) {
+					if answered == true {
+						Q := step(q, a)
+						forEachBit(step(p, a), func(p_ int) {
+							answered = answered && intersects(Q, simulatedBy[p_])
+						})
+					}
+				})
+				if answered == false {
+					simulatedBy[p].Remove(bitset.BitPosition(q))
+					changed = true
+				}
+			})
+		})
+	}
+
+	n := A.stateSet.Size()
+	R := simulationRelation{make([]State, 0, n), make(map[State]int), make([][]bool, n)}
+	forEachBit(*A.stateSet, func(q int) {
+		R.index[A.states.elements[q].(State)] = len(R.states)
+		R.states = append(R.states, A.states.elements[q].(State))
+	})
+	for i, p := range R.states {
+		R.simulates[i] = make([]bool, n)
+		for j, q := range R.states {
+			p_, _ := A.states.lookup(p)
+			q_, _ := A.states.lookup(q)
+			R.simulates[i][j] = simulatedBy[p_].Probe(bitset.BitPosition(q_))
+		}
+	}
+
+	return R
+}
+
+// the predecessors of each state for each letter, the backward counterpart of A.successors
+func (A *compactNfa) predecessors() [][]*bitset.BitSet {
+	ret := make([][]*bitset.BitSet, len(A.states.elements))
+	for q, m := range A.successors {
+		for a, S := range m {
+			if S != nil {
+				forEachBit(*S, func(r int) {
+					for len(ret[r]) <= a {
+						ret[r] = append(ret[r], new(bitset.BitSet))
+					}
+					ret[r][a].Add(bitset.BitPosition(q))
+				})
+			}
+		}
+	}
+	return ret
+}
+
+func intersects(S, T bitset.BitSet) bool {
+	for i := 0; i < len(S) && i < len(T); i += 1 {
+		if S[i]&T[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// equal for equal sets, regardless of trailing zero words
+func bitsKey(S bitset.BitSet) string {
+	n := len(S)
+	for n > 0 && S[n-1] == 0 {
+		n -= 1
+	}
+	return fmt.Sprint(S[:n])
+}
@@ -0,0 +1,173 @@
+package nfa
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hydroo/gomochex/basic/set"
+	"testing"
+)
+
+func TestCompactNfaConversion(t *testing.T) {
+	inputs := [][]byte{
+		epsilonAStarB,
+		simulationExample,
+		[]byte(`{"States":["x","0","π"],"Alphabet":["b","a"],"InitialStates":["π","x"],"Transitions":{"x":{"a":["0","π"]},"π":{"b":["π"]}},"FinalStates":["0"]}`),
+	}
+
+	for k, s := range inputs {
+		A := NewNfa()
+		json.Unmarshal(s, &A)
+
+		C := ToCompactNfa(A)
+		if _, ok := C.(*compactNfa); ok == false {
+			t.Fatal()
+		}
+		B := ToSimpleNfa(C)
+
+		if A.IsEqual(C) != true || C.IsEqual(A) != true || A.IsEqual(B) != true {
+			t.Error("case", k, C)
+		}
+		if fmt.Sprint(C.States()) != fmt.Sprint(A.States()) || fmt.Sprint(B.States()) != fmt.Sprint(A.States()) || fmt.Sprint(C.Alphabet()) != fmt.Sprint(A.Alphabet()) {
+			t.Error("case", k, C.States(), C.Alphabet())
+		}
+		if C.String() != ToSimpleNfa(C).String() {
+			t.Error("case", k, C)
+		}
+
+		D := NewCompactNfa()
+		if err := json.Unmarshal(s, &D); err != nil || D.IsEqual(A) != true {
+			t.Error("case", k, err, D)
+		}
+	}
+
+	// undeclared states and letters survive the conversion
+	A := NewNfa()
+	A.SetTransition("0", "a", set.NewSet(State("1")))
+	if err := ToCompactNfa(A).Validate(); err == nil || err.Error() != A.Validate().Error() {
+		t.Error(err)
+	}
+}
+
+func TestCompactNfaSets(t *testing.T) {
+	A := NewCompactNfa()
+	A.States().Add(State("0"), State("1"), State("2"))
+	A.Alphabet().Add(Letter("a"))
+	A.InitialStates().Add(State("0"))
+	A.FinalStates().Add(State("2"))
+	A.SetTransition("0", "a", set.NewSet(State("1")))
+	A.SetTransition("1", "a", set.NewSet(State("2")))
+	A.Transition("1", "a").Add(State("0"))
+	A.SetEpsilonTransition("2", set.NewSet(State("0")))
+
+	// missing transitions are read without being stored
+	if A.Transition("2", "a").Size() != 0 || A.EpsilonTransition("0").Size() != 0 || len(A.(*compactNfa).successors) != 2 || len(A.(*compactNfa).epsilonSuccessors) != 3 || A.(*compactNfa).epsilonSuccessors[0] != nil {
+		t.Error(A)
+	}
+
+	expected := NewNfa()
+	json.Unmarshal([]byte(`{"States":["0","1","2"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"1":{"a":["0","2"]}},"EpsilonTransitions":{"2":["0"]},"FinalStates":["2"]}`), &expected)
+	if A.IsEqual(expected) != true {
+		t.Error(A)
+	}
+
+	S := A.Transition("1", "a")
+	if S.Size() != 2 || S.Probe(State("2")) != true || S.Probe(State("x")) != false || S.IsEqual(set.NewSet(State("2"), State("0"))) != true {
+		t.Error(S)
+	}
+	if s, ok := S.At(1); ok != true || s != State("2") {
+		t.Error(s)
+	}
+	if _, ok := S.At(2); ok != false {
+		t.Error()
+	}
+
+	// copies are independent of the automaton
+	T := S.Copy().(StateSet)
+	T.Add(State("1"))
+	if S.Size() != 2 {
+		t.Error(S)
+	}
+
+	S.Remove(State("0"))
+	if A.Transition("1", "a").IsEqual(set.NewSet(State("2"))) != true {
+		t.Error(A)
+	}
+
+	A.States().Remove(State("1"))
+	if A.States().Size() != 2 || A.States().Probe(State("1")) != false {
+		t.Error(A.States())
+	}
+
+	// copies of automata are independent, too
+	B := A.Copy()
+	B.States().Add(State("3"))
+	B.Transition("0", "a").Add(State("3"))
+	if A.States().Size() != 2 || A.Transition("0", "a").Size() != 1 {
+		t.Error(A)
+	}
+
+	A.SetTransition("0", "a", set.NewSet())
+	if A.Transition("0", "a").Size() != 0 {
+		t.Error(A)
+	}
+}
+
+// the algorithms give the same results for both implementations
+func TestCompactNfaAlgorithms(t *testing.T) {
+	inputs := [][]byte{
+		epsilonAStarB,
+		simulationExample,
+		[]byte(`{"States":["0","1","2"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["0","1"],"b":["0"]},"1":{"b":["2"]}},"FinalStates":["2"]}`),
+	}
+
+	for k, s := range inputs {
+		A := NewNfa()
+		json.Unmarshal(s, &A)
+		C := ToCompactNfa(A)
+
+		if D, E := determinize(A, A.Alphabet()), determinize(C, C.Alphabet()); D.IsEqual(E) != true {
+			t.Error("case", k, D, E)
+		}
+		if CountWords(A, 5).Cmp(CountWords(C, 5)) != 0 {
+			t.Error("case", k)
+		}
+		if fmt.Sprint(Words(A, 20)) != fmt.Sprint(Words(C, 20)) {
+			t.Error("case", k, Words(C, 20))
+		}
+		if A.EpsilonClosure(A.InitialStates()).IsEqual(C.EpsilonClosure(C.InitialStates())) != true {
+			t.Error("case", k)
+		}
+		if A.EliminateEpsilons().IsEqual(C.EliminateEpsilons()) != true || A.Trim().IsEqual(C.Trim()) != true {
+			t.Error("case", k)
+		}
+		if empty, w := IsEmpty(C); empty == true || C.Accepts(w) != true {
+			t.Error("case", k, w)
+		}
+		if QuotientByBisimulation(A).IsEqual(QuotientByBisimulation(C)) != true || Bisimulation(A, C) != true {
+			t.Error("case", k)
+		}
+		if fmt.Sprint(ForwardSimulation(A)) != fmt.Sprint(ForwardSimulation(C)) || fmt.Sprint(BackwardSimulation(A)) != fmt.Sprint(BackwardSimulation(C)) {
+			t.Error("case", k, ForwardSimulation(C), BackwardSimulation(C))
+		}
+		if B, _, _ := ReduceBySimulation(C); B.IsEqual(ToCompactNfa(reduced(A))) != true {
+			t.Error("case", k, B)
+		}
+
+		for _, B := range []Nfa{Complement(C, C.Alphabet()), Reverse(C), KleeneStar(C.Copy()), Concat(C, C), Intersect(C, A), Shuffle(C, OneLetter("a")), Prefixes(C)} {
+			if B.Validate() != nil {
+				t.Error("case", k, B.Validate())
+			}
+		}
+		if equal, w := Equivalent(Complement(A, A.Alphabet()), Complement(C, C.Alphabet())); equal != true {
+			t.Error("case", k, w)
+		}
+		if equal, w := Equivalent(Intersect(C, C), A); equal != true {
+			t.Error("case", k, w)
+		}
+	}
+}
+
+func reduced(A Nfa) Nfa {
+	B, _, _ := ReduceBySimulation(A)
+	return B
+}
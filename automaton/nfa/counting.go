@@ -0,0 +1,90 @@
+package nfa
+
+import (
+	"math/big"
+	"math/rand"
+)
+
+// the number of accepted words of length n, 0 for negative n
+func CountWords(A Nfa, n int) *big.Int {
+	if n < 0 {
+		return big.NewInt(0)
+	}
+
+	B := determinize(A, A.Alphabet())
+	counts := countWords(B, n)
+
+	q, _ := B.InitialStates().At(0)
+	return counts[n][q.(State)]
+}
+
+// draws an accepted word of length n uniformly at random.
+// returns false if there is no such word, in particular for negative n.
+func SampleWord(A Nfa, n int, rng *rand.Rand) ([]Letter, bool) {
+	if n < 0 {
+		return nil, false
+	}
+
+	B := determinize(A, A.Alphabet())
+	counts := countWords(B, n)
+
+	q_, _ := B.InitialStates().At(0)
+	q := q_.(State)
+
+	if counts[n][q].Sign() == 0 {
+		return nil, false
+	}
+
+	word := make([]Letter, n)
+
+	for k := n; k > 0; k -= 1 {
+		// pick one of the counts[k][q] words, then find the letter it starts with
+		r := new(big.Int).Rand(rng, counts[k][q])
+
+		for i := 0; i < B.Alphabet().Size(); i += 1 {
+			a, _ := B.Alphabet().At(i)
+			p, _ := B.Transition(q, a.(Letter)).At(0)
+
+			if r.Cmp(counts[k-1][p.(State)]) < 0 {
+				word[n-k] = a.(Letter)
+				q = p.(State)
+				break
+			}
+			r.Sub(r, counts[k-1][p.(State)])
+		}
+	}
+
+	return word, true
+}
+
+// counts[k][q] is the number of words of length k accepted from q
+// B has to be deterministic and complete
+func countWords(B Nfa, n int) []map[State]*big.Int {
+	counts := make([]map[State]*big.Int, n+1)
+
+	counts[0] = make(map[State]*big.Int)
+	for i := 0; i < B.States().Size(); i += 1 {
+		q, _ := B.States().At(i)
+		if B.FinalStates().Probe(q) == true {
+			counts[0][q.(State)] = big.NewInt(1)
+		} else {
+			counts[0][q.(State)] = big.NewInt(0)
+		}
+	}
+
+	for k := 1; k <= n; k += 1 {
+		counts[k] = make(map[State]*big.Int)
+		for i := 0; i < B.States().Size(); i += 1 {
+			q, _ := B.States().At(i)
+			c := big.NewInt(0)
+			for j := 0; j < B.Alphabet().Size(); j += 1 {
+				a, _ := B.Alphabet().At(j)
+				p, _ := B.Transition(q.(State), a.(Letter)).At(0)
+				c.Add(c, counts[k-1][p.(State)])
+			}
+			counts[k][q.(State)] = c
+		}
+	}
+
+	return counts
+}
@@ -0,0 +1,85 @@
+package nfa
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestCountWords(t *testing.T) {
+	type test struct {
+		A      Nfa
+		n      int
+		should string
+	}
+
+	// (a+b)*.a.(a+b), built in two ways with lots of ambiguity
+	ab := func() Nfa { return Union(OneLetter("a"), OneLetter("b")) }
+	secondToLastIsA := Concat(Concat(KleeneStar(ab()), OneLetter("a")), ab())
+
+	tests := []test{
+		test{OneLetter("a"), 0, "0"},
+		test{OneLetter("a"), 1, "1"},
+		test{KleeneStar(OneLetter("a")), 0, "1"},
+		test{KleeneStar(ab()), 10, "1024"},
+		test{secondToLastIsA, 1, "0"},
+		test{secondToLastIsA, 2, "2"},
+		test{secondToLastIsA, 10, "512"},
+		test{KleeneStar(ab()), 100, "1267650600228229401496703205376"},
+		test{NewNfa(), 3, "0"},
+		test{KleeneStar(ab()), -1, "0"},
+	}
+
+	for k, x := range tests {
+		if c := CountWords(x.A, x.n); c.String() != x.should {
+			t.Error("case ", k, " should:", x.should, " is:", c)
+		}
+	}
+}
+
+func TestSampleWord(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// (a+(b.b))*: words of length 4 are aaaa, aabb, abba, bbaa, bbbb
+	A := KleeneStar(Union(OneLetter("a"), Concat(OneLetter("b"), OneLetter("b"))))
+
+	if c := CountWords(A, 4); c.Cmp(big.NewInt(5)) != 0 {
+		t.Error(c)
+	}
+
+	histogram := make(map[string]int)
+	for i := 0; i < 5000; i += 1 {
+		w, ok := SampleWord(A, 4, rng)
+		if ok != true || A.Accepts(w) != true || len(w) != 4 {
+			t.Error(w, ok)
+		}
+		histogram[fmt.Sprint(w)] += 1
+	}
+
+	if len(histogram) != 5 {
+		t.Error(histogram)
+	}
+	for w, c := range histogram {
+		if c < 850 || c > 1150 {
+			t.Error("not uniform: ", w, c)
+		}
+	}
+
+	// reproducible
+	u, _ := SampleWord(A, 10, rand.New(rand.NewSource(7)))
+	v, _ := SampleWord(A, 10, rand.New(rand.NewSource(7)))
+	if fmt.Sprint(u) != fmt.Sprint(v) {
+		t.Error(u, v)
+	}
+
+	if w, ok := SampleWord(A, 3, rng); ok != true || A.Accepts(w) != true {
+		t.Error(w, ok)
+	}
+	if w, ok := SampleWord(OneLetter("a"), 2, rng); ok != false || w != nil {
+		t.Error(w, ok)
+	}
+	if w, ok := SampleWord(A, -1, rng); ok != false || w != nil {
+		t.Error(w, ok)
+	}
+}
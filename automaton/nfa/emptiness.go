@@ -0,0 +1,90 @@
+package nfa
+
+// breadth first search from the initial states
+// if the language of A is not empty, a shortest accepted word is returned
+func IsEmpty(A Nfa) (bool, []Letter) {
+	if C, ok := A.(*compactNfa); ok == true {
+		return C.isEmpty()
+	}
+
+	// how a state was reached with the shortest word known so far
+	type origin struct {
+		predecessor State
+		letter      Letter
+		initial     bool
+		epsilon     bool
+		length      int
+	}
+
+	origins := make(map[State]origin)
+	visited := make(map[State]bool)
+
+	// epsilon transitions do not lengthen the word, hence their targets go to the front (0-1 BFS).
+	// the deque is a stack for the front, and a queue with a head index for the back
+	front := make([]State, 0)
+	back := make([]State, 0)
+	head := 0
+
+	var relax func(State, origin)
+	relax = func(q State, o origin) {
+		if p, ok := origins[q]; ok == true && p.length <= o.length {
+			return
+		}
+		origins[q] = o
+		if o.epsilon == true || o.initial == true {
+			front = append(front, q)
+		} else {
+			back = append(back, q)
+		}
+	}
+
+	for i := 0; i < A.InitialStates().Size(); i += 1 {
+		q, _ := A.InitialStates().At(i)
+		relax(q.(State), origin{"", "", true, false, 0})
+	}
+
+	for len(front) > 0 || head < len(back) {
+		var q State
+		if len(front) > 0 {
+			q = front[len(front)-1]
+			front = front[:len(front)-1]
+		} else {
+			q = back[head]
+			head += 1
+		}
+
+		if visited[q] == true {
+			continue
+		}
+		visited[q] = true
+
+		if A.FinalStates().Probe(q) == true {
+			word := make([]Letter, origins[q].length)
+			for o := origins[q]; o.initial == false; o = origins[o.predecessor] {
+				if o.epsilon == false {
+					word[o.length-1] = o.letter
+				}
+			}
+			return false, word
+		}
+
+		length := origins[q].length
+
+		Q := A.EpsilonTransition(q)
+		for i := 0; i < Q.Size(); i += 1 {
+			r, _ := Q.At(i)
+			relax(r.(State), origin{q, "", false, true, length})
+		}
+
+		for i := 0; i < A.Alphabet().Size(); i += 1 {
+			a, _ := A.Alphabet().At(i)
+			Q := A.Transition(q, a.(Letter))
+			for j := 0; j < Q.Size(); j += 1 {
+				r, _ := Q.At(j)
+				relax(r.(State), origin{q, a.(Letter), false, false, length + 1})
+			}
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,81 @@
+package nfa
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hydroo/gomochex/basic/set"
+	"testing"
+)
+
+func TestIsEmpty(t *testing.T) {
+	type test struct {
+		A             Nfa
+		shouldBeEmpty bool
+		word          []Letter
+	}
+
+	epsilon := NewNfa()
+	json.Unmarshal([]byte(`{"States":["0","1","2","3"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["2"]},"1":{"b":["3"]},"2":{"a":["3"]}},"EpsilonTransitions":{"0":["1"]},"FinalStates":["3"]}`), &epsilon)
+
+	// the epsilon transition reaches 2 with a shorter word than the a transition
+	shortcut := NewNfa()
+	json.Unmarshal([]byte(`{"States":["0","1","2","3"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["2"]},"2":{"b":["3"]}},"EpsilonTransitions":{"0":["1"],"1":["2"]},"FinalStates":["3"]}`), &shortcut)
+
+	// a long chain of epsilon transitions, every state also has an a transition to the end.
+	// the chain is shorter, even though the a transitions are found first
+	chain := NewNfa()
+	chain.Alphabet().Add(Letter("a"))
+	chain.States().Add(State("end"))
+	chain.InitialStates().Add(State("0"))
+	for i := 0; i < 1000; i += 1 {
+		q := State(fmt.Sprint(i))
+		chain.States().Add(q)
+		chain.SetTransition(q, "a", set.NewSet(State("end")))
+		chain.SetEpsilonTransition(q, set.NewSet(State(fmt.Sprint(i+1))))
+	}
+	chain.States().Add(State("1000"))
+	chain.FinalStates().Add(State("1000"), State("end"))
+
+	tests := []test{
+		test{NewNfa(), true, nil},
+		test{OneLetter("a"), false, []Letter{"a"}},
+		test{KleeneStar(OneLetter("a")), false, []Letter{}},
+		test{Concat(Concat(OneLetter("a"), KleeneStar(OneLetter("b"))), OneLetter("c")), false, []Letter{"a", "c"}},
+		test{Intersect(OneLetter("a"), OneLetter("b")), true, nil},
+		test{Difference(KleeneStar(OneLetter("a")), KleeneStar(OneLetter("a"))), true, nil},
+		test{Difference(KleeneStar(OneLetter("a")), Concat(OneLetter("a"), OneLetter("a"))), false, []Letter{}},
+		test{epsilon, false, []Letter{"b"}},
+		test{shortcut, false, []Letter{"b"}},
+		test{chain, false, []Letter{}},
+	}
+
+	for k, x := range tests {
+		empty, word := IsEmpty(x.A)
+		if empty != x.shouldBeEmpty || fmt.Sprint(word) != fmt.Sprint(x.word) || (word == nil) != (x.word == nil) {
+			t.Error("case ", k, " should:", x.shouldBeEmpty, x.word, " is:", empty, word)
+		}
+		if word != nil && x.A.Accepts(word) != true {
+			t.Error("case ", k, " not accepted:", word)
+		}
+		if e, w := IsEmpty(ToCompactNfa(x.A)); e != empty || fmt.Sprint(w) != fmt.Sprint(word) {
+			t.Error("case ", k, " compact:", e, w)
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	// same automaton as in TestRemoveUselessParts, with an extra letter
+	s := []byte(`{
+		"States":["0","1","2","3","4","5","6","7","8"],
+		"Alphabet":["a","b"],
+		"InitialStates":["0","4","7"],
+		"Transitions":{"0":{"a":["1"]},"2":{"a":["3"]},"4":{"a":["5"]},"6":{"a":["0"]},"7":{"a":["8"]}},
+		"FinalStates":["0","3","8"]
+	}`)
+	u := []byte(`{"States":["0","7","8"],"Alphabet":["a","b"],"InitialStates":["0","7"],"Transitions":{"7":{"a":["8"]}},"FinalStates":["0","8"]}`)
+
+	A := NewNfa()
+	json.Unmarshal(s, &A)
+	compareNfaToMarshaledNfa(A.Trim(), u, true, t, "")
+	compareNfaToMarshaledNfa(ToCompactNfa(A).Trim(), u, true, t, "")
+}
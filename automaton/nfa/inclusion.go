@@ -0,0 +1,124 @@
+package nfa
+
+import (
+	"github.com/hydroo/gomochex/basic/set"
+)
+
+// L(A) ⊆ L(B)
+//
+// antichain algorithm: A is explored together with the subset construction of B,
+// but a pair (p, S) is dropped if a pair (p, S') with S' ⊆ S has been seen already.
+// the search is breadth first, hence the returned counterexample is a shortest word
+// in L(A) \ L(B). it is nil if A is included in B.
+func Includes(A, B Nfa) (bool, []Letter) {
+	A = withoutEpsilons(A)
+	B = withoutEpsilons(B)
+
+	sigma := set.Join(A.Alphabet(), B.Alphabet())
+
+	type macroState struct {
+		p    State
+		S    StateSet
+		word []Letter
+	}
+
+	antichain := make(map[State][]StateSet)
+
+	// returns false if (p, S) is subsumed by the antichain
+	var insert func(State, StateSet) bool
+	insert = func(p State, S StateSet) bool {
+		for _, T := range antichain[p] {
+			if isSubset(T, S) == true {
+				return false
+			}
+		}
+
+		minimal := make([]StateSet, 0, len(antichain[p])+1)
+		for _, T := range antichain[p] {
+			if isSubset(S, T) == false {
+				minimal = append(minimal, T)
+			}
+		}
+		antichain[p] = append(minimal, S)
+
+		return true
+	}
+
+	queue := make([]macroState, 0)
+
+	for i := 0; i < A.InitialStates().Size(); i += 1 {
+		p, _ := A.InitialStates().At(i)
+		S := B.InitialStates().Copy().(StateSet)
+		if insert(p.(State), S) == true {
+			queue = append(queue, macroState{p.(State), S, []Letter{}})
+		}
+	}
+
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+
+		if A.FinalStates().Probe(m.p) == true && set.Intersect(m.S, B.FinalStates()).Size() == 0 {
+			return false, m.word
+		}
+
+		for i := 0; i < sigma.Size(); i += 1 {
+			a_, _ := sigma.At(i)
+			a := a_.(Letter)
+
+			P := A.Transition(m.p, a)
+			if P.Size() == 0 {
+				continue
+			}
+
+			S := set.NewSet()
+			for j := 0; j < m.S.Size(); j += 1 {
+				s, _ := m.S.At(j)
+				S = set.Join(S, B.Transition(s.(State), a))
+			}
+
+			for j := 0; j < P.Size(); j += 1 {
+				p, _ := P.At(j)
+				if insert(p.(State), S) == true {
+					word := make([]Letter, len(m.word)+1)
+					copy(word, m.word)
+					word[len(m.word)] = a
+					queue = append(queue, macroState{p.(State), S, word})
+				}
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// L(A) = L(B)
+// the counterexample is a shortest word in the symmetric difference of both languages, or nil
+func Equivalent(A, B Nfa) (bool, []Letter) {
+	includedAB, counterexampleAB := Includes(A, B)
+	includedBA, counterexampleBA := Includes(B, A)
+
+	switch {
+	case includedAB == true && includedBA == true:
+		return true, nil
+	case includedAB == false && includedBA == false && len(counterexampleBA) < len(counterexampleAB):
+		return false, counterexampleBA
+	case includedAB == false:
+		return false, counterexampleAB
+	}
+	return false, counterexampleBA
+}
+
+// S ⊆ T
+func isSubset(S, T StateSet) bool {
+	if S.Size() > T.Size() {
+		return false
+	}
+	for i := 0; i < S.Size(); i += 1 {
+		s, _ := S.At(i)
+		if T.Probe(s) == false {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package nfa
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIncludes(t *testing.T) {
+	a := OneLetter("a")
+	b := OneLetter("b")
+
+	type test struct {
+		A, B           Nfa
+		shouldInclude  bool
+		counterexample []Letter
+	}
+
+	tests := []test{
+		test{Union(OneLetter("a"), OneLetter("b")), Union(OneLetter("b"), OneLetter("a")), true, nil},
+		test{KleeneStar(Concat(OneLetter("a"), OneLetter("a"))), KleeneStar(OneLetter("a")), true, nil},
+		test{KleeneStar(OneLetter("a")), KleeneStar(Concat(OneLetter("a"), OneLetter("a"))), false, []Letter{"a"}},
+		test{Concat(a, b), Concat(OneLetter("a"), Union(OneLetter("b"), OneLetter("c"))), true, nil},
+		test{Concat(OneLetter("a"), Union(OneLetter("b"), OneLetter("c"))), Concat(a, b), false, []Letter{"a", "c"}},
+		test{KleeneStar(OneLetter("a")), Concat(OneLetter("a"), KleeneStar(OneLetter("a"))), false, []Letter{}},
+		test{KleeneStar(Union(OneLetter("a"), OneLetter("b"))), Union(KleeneStar(OneLetter("a")), KleeneStar(OneLetter("b"))), false, []Letter{"a", "b"}},
+		test{NewNfa(), a, true, nil},
+	}
+
+	for k, x := range tests {
+		included, counterexample := Includes(x.A, x.B)
+		if included != x.shouldInclude || fmt.Sprint(counterexample) != fmt.Sprint(x.counterexample) || (counterexample == nil) != (x.counterexample == nil) {
+			t.Error("case ", k, " should:", x.shouldInclude, x.counterexample, " is:", included, counterexample)
+		}
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	type test struct {
+		A, B           Nfa
+		shouldBeEqual  bool
+		counterexample []Letter
+	}
+
+	tests := []test{
+		test{Union(OneLetter("a"), OneLetter("b")), Union(OneLetter("b"), OneLetter("a")), true, nil},
+		test{Concat(KleeneStar(OneLetter("a")), KleeneStar(OneLetter("a"))), KleeneStar(OneLetter("a")), true, nil},
+		test{Concat(Union(OneLetter("a"), OneLetter("b")), OneLetter("c")), Union(Concat(OneLetter("a"), OneLetter("c")), Concat(OneLetter("b"), OneLetter("c"))), true, nil},
+		test{KleeneStar(Union(OneLetter("a"), OneLetter("b"))), Union(OneLetter("a"), OneLetter("b")), false, []Letter{}},
+		test{Concat(OneLetter("a"), OneLetter("b")), Concat(OneLetter("a"), Concat(OneLetter("b"), OneLetter("b"))), false, []Letter{"a", "b"}},
+		test{Concat(OneLetter("a"), Concat(OneLetter("b"), OneLetter("b"))), Concat(OneLetter("a"), OneLetter("b")), false, []Letter{"a", "b"}},
+	}
+
+	for k, x := range tests {
+		equal, counterexample := Equivalent(x.A, x.B)
+		if equal != x.shouldBeEqual || fmt.Sprint(counterexample) != fmt.Sprint(x.counterexample) || (counterexample == nil) != (x.counterexample == nil) {
+			t.Error("case ", k, " should:", x.shouldBeEqual, x.counterexample, " is:", equal, counterexample)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package nfa
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/hydroo/gomochex/basic/set"
+	"io"
+	"sort"
+	"strings"
 )
 
 type State string
@@ -49,8 +54,23 @@ type Nfa interface {
 	SetTransition(State, Letter, StateSet)
 	SetTransitionFunction(func(State, Letter) StateSet)
 
+	EpsilonTransition(State) StateSet
+	SetEpsilonTransition(State, StateSet)
+	// all states reachable by epsilon transitions, including the given ones
+	EpsilonClosure(StateSet) StateSet
+	// an equivalent Nfa with the same states, but without epsilon transitions
+	EliminateEpsilons() Nfa
+
+	Accepts([]Letter) bool
+	AcceptingRun([]Letter) ([]State, error)
+
+	// removes unreachable states and states from which no final state is reachable
+	Trim() Nfa
+
 	Copy() Nfa
 
+	// returns a ValidationError if states or letters are used without being declared
+	Validate() error
 
 	String() string
 	json.Marshaler
@@ -58,10 +78,65 @@ type Nfa interface {
 	IsEqual(Nfa) bool
 }
 
+// is returned if a word contains a letter which is not in the alphabet
+type LetterError struct {
+	Letter   Letter
+	Position int
+}
+
+func (e LetterError) Error() string {
+	return fmt.Sprint("letter ", e.Letter, " at position ", e.Position, " is not in the alphabet")
+}
+
+// a state or letter which is used, but not declared in States or Alphabet
+type Inconsistency struct {
+	Field      string // where it is used: InitialStates, FinalStates, Transitions or EpsilonTransitions
+	Undeclared string // "state" or "letter"
+	Name       string
+}
+
+func (i Inconsistency) String() string {
+	return fmt.Sprint("undeclared ", i.Undeclared, " ", i.Name, " in ", i.Field)
+}
+
+// is returned by Validate and UnmarshalJSON, lists every inconsistency
+type ValidationError struct {
+	Inconsistencies []Inconsistency
+}
+
+func (e ValidationError) Error() string {
+	ret := "invalid nfa:"
+	for k, i := range e.Inconsistencies {
+		if k > 0 {
+			ret += ","
+		}
+		ret += " " + i.String()
+	}
+	return ret
+}
+
 func NewNfa() Nfa {
-	return &simpleNfa{set.NewSet(), set.NewSet(), set.NewSet(), make(map[State]map[Letter]StateSet), set.NewSet()}
+	return &simpleNfa{set.NewSet(), set.NewSet(), set.NewSet(), make(map[State]map[Letter]StateSet), make(map[State]StateSet), set.NewSet()}
 }
 
+// words over sigma which A does not accept
+// A is determinized and completed with a sink state, then final and non-final states are swapped
+func Complement(A Nfa, sigma Alphabet) Nfa {
+	B := determinize(A, sigma)
+
+	F := set.NewSet()
+	for i := 0; i < B.States().Size(); i += 1 {
+		q, _ := B.States().At(i)
+		if B.FinalStates().Probe(q) == false {
+			F.Add(q)
+		}
+	}
+	B.SetFinalStates(F)
+
+	return B
+}
+
+// A's final states get epsilon transitions to B's initial states
 func Concat(A, B Nfa) Nfa {
 	C := NewNfa()
 
@@ -70,6 +145,15 @@ func Concat(A, B Nfa) Nfa {
 	//add B as it is with a 1 prepended to all states
 	//add A as it is with a 0 prepended to all states
 	for k, T := range []Nfa{A, B} {
+		prefix := func(S StateSet) StateSet {
+			S_ := set.NewSet()
+			for l := 0; l < S.Size(); l += 1 {
+				t, _ := S.At(l)
+				S_.Add(State(fmt.Sprint(k, t)))
+			}
+			return S_
+		}
+
 		for i := 0; i < T.States().Size(); i += 1 {
 			s, _ := T.States().At(i)
 			s_ := State(fmt.Sprint(k, s))
@@ -84,92 +168,249 @@ func Concat(A, B Nfa) Nfa {
 
 			for j := 0; j < T.Alphabet().Size(); j += 1 {
 				a, _ := T.Alphabet().At(j)
-				S := T.Transition(s.(State), a.(Letter))
-
-				S_ := set.NewSet()
-				for l := 0; l < S.Size(); l += 1 {
-					t, _ := S.At(l)
-					S_.Add(State(fmt.Sprint(k, t)))
-				}
-
-				C.SetTransition(s_, a.(Letter), S_)
+				C.SetTransition(s_, a.(Letter), prefix(T.Transition(s.(State), a.(Letter))))
 			}
+			C.SetEpsilonTransition(s_, prefix(T.EpsilonTransition(s.(State))))
 		}
 	}
 
-	// add transititons from before final states in A to all initial states in B
+	// add epsilon transitions from the final states in A to all initial states in B
 	S_ := set.NewSet()
 	for i := 0; i < B.InitialStates().Size(); i += 1 {
 		s, _ := B.InitialStates().At(i)
 		S_.Add(State(fmt.Sprint(1, s)))
 	}
 
-	for i := 0; i < A.States().Size(); i += 1 {
-		s, _ := A.States().At(i)
+	for i := 0; i < A.FinalStates().Size(); i += 1 {
+		s, _ := A.FinalStates().At(i)
 		s_ := State(fmt.Sprint(0, s))
 
-		for j := 0; j < A.Alphabet().Size(); j += 1 {
-			a, _ := A.Alphabet().At(j)
-			S := A.Transition(s.(State), a.(Letter))
-
-			if set.Intersect(A.FinalStates(), S).Size() > 0 {
-				C.SetTransition(s_, a.(Letter), set.Join(A.Transition(s_, a.(Letter)), S_))
-			}
-		}
+		C.SetEpsilonTransition(s_, set.Join(C.EpsilonTransition(s_), S_))
 	}
 
 	return C
 }
 
-func KleeneStar(A Nfa) Nfa {
+// L(A) \ L(B)
+func Difference(A, B Nfa) Nfa {
+	return Intersect(A, Complement(B, set.Join(A.Alphabet(), B.Alphabet())))
+}
 
-	var q0 State
-	for i := 0; ; i += 1 {
-		q0 = State(fmt.Sprint(i))
-		if A.States().Probe(q0) == false {
-			break
+// does any state have an epsilon transition
+func HasEpsilonTransitions(A Nfa) bool {
+	for i := 0; i < A.States().Size(); i += 1 {
+		q, _ := A.States().At(i)
+		if A.EpsilonTransition(q.(State)).Size() > 0 {
+			return true
 		}
 	}
+	return false
+}
 
-	A.States().Add(q0)
+// replaces every letter a by the word h[a]. letters without image are kept.
+// a transition for a word of several letters becomes a chain of new states (q,a,r,i),
+// primed until they are fresh, a transition for the empty word becomes an epsilon transition.
+func Homomorphism(A Nfa, h map[Letter][]Letter) Nfa {
+	B := NewNfa()
+	B.SetStates(A.States().Copy().(StateSet))
+	B.SetInitialStates(A.InitialStates().Copy().(StateSet))
+	B.SetFinalStates(A.FinalStates().Copy().(StateSet))
+
+	image := func(a Letter) []Letter {
+		if w, ok := h[a]; ok == true {
+			return w
+		} //else {
+		return []Letter{a}
+		//}
+	}
+
+	for i := 0; i < A.Alphabet().Size(); i += 1 {
+		a, _ := A.Alphabet().At(i)
+		for _, b := range image(a.(Letter)) {
+			B.Alphabet().Add(b)
+		}
+	}
 
-	// add transitions from before final states to the new initial state
 	for i := 0; i < A.States().Size(); i += 1 {
-		for j := 0; j < A.Alphabet().Size(); j += 1 {
+		q_, _ := A.States().At(i)
+		q := q_.(State)
 
-			q_, _ := A.States().At(i)
-			q := q_.(State)
+		B.SetEpsilonTransition(q, A.EpsilonTransition(q).Copy().(StateSet))
 
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
 			a_, _ := A.Alphabet().At(j)
 			a := a_.(Letter)
+			w := image(a)
 
-			Q := A.Transition(q, a)
+			R := A.Transition(q, a)
+			for k := 0; k < R.Size(); k += 1 {
+				r_, _ := R.At(k)
+				r := r_.(State)
+
+				if len(w) == 0 {
+					B.SetEpsilonTransition(q, set.Join(B.EpsilonTransition(q), set.NewSet(r)))
+					continue
+				}
+
+				p := q
+				for l, b := range w {
+					p_ := r
+					if l < len(w)-1 {
+						p_ = tupleName(q, a, r, l)
+						for B.States().Probe(p_) == true {
+							p_ += "'"
+						}
+						B.States().Add(p_)
+					}
+					B.SetTransition(p, b, set.Join(B.Transition(p, b), set.NewSet(p_)))
+					p = p_
+				}
+			}
+		}
+	}
 
-			if set.Intersect(Q, A.FinalStates()).Size() != 0 {
-				Q.Add(q0)
-				A.SetTransition(q, a, Q)
+	return B
+}
+
+// all factors of words in L(A)
+func Infixes(A Nfa) Nfa {
+	B := A.Trim()
+	B.SetInitialStates(B.States().Copy().(StateSet))
+	B.SetFinalStates(B.States().Copy().(StateSet))
+	return B
+}
+
+// synchronous product
+// the state (p,q) stands for p in A and q in B. only reachable pairs are added.
+func Intersect(A, B Nfa) Nfa {
+	C, _ := intersect(A, B)
+	return C
+}
+
+// see Intersect, pairs maps every state of the product to the pair it stands for
+func intersect(A, B Nfa) (C Nfa, pairs map[State]statePair) {
+	A = withoutEpsilons(A)
+	B = withoutEpsilons(B)
+
+	C = NewNfa()
+	pairs = make(map[State]statePair)
+
+	C.SetAlphabet(set.Join(A.Alphabet(), B.Alphabet()))
+
+	queue := make([]statePair, 0)
+
+	var add func(State, State) State
+	add = func(p, q State) State {
+		r := tupleName(p, q)
+		if C.States().Probe(r) == false {
+			C.States().Add(r)
+			pairs[r] = statePair{p, q}
+			if A.FinalStates().Probe(p) == true && B.FinalStates().Probe(q) == true {
+				C.FinalStates().Add(r)
 			}
+			queue = append(queue, statePair{p, q})
 		}
+		return r
 	}
 
-	// add transitions from the new initial state
-	// to the destinations of the old initial states
 	for i := 0; i < A.InitialStates().Size(); i += 1 {
-		for j := 0; j < A.Alphabet().Size(); j += 1 {
+		for j := 0; j < B.InitialStates().Size(); j += 1 {
+			p, _ := A.InitialStates().At(i)
+			q, _ := B.InitialStates().At(j)
+			C.InitialStates().Add(add(p.(State), q.(State)))
+		}
+	}
 
-			q_, _ := A.InitialStates().At(i)
-			q := q_.(State)
+	for len(queue) > 0 {
+		x := queue[0]
+		queue = queue[1:]
 
-			a_, _ := A.Alphabet().At(j)
+		for i := 0; i < C.Alphabet().Size(); i += 1 {
+			a_, _ := C.Alphabet().At(i)
 			a := a_.(Letter)
 
-			Q := A.Transition(q, a)
-			Q0 := A.Transition(q0, a)
+			P := A.Transition(x.p, a)
+			Q := B.Transition(x.q, a)
 
-			A.SetTransition(q0, a, set.Join(Q, Q0))
+			R := set.NewSet()
+			for j := 0; j < P.Size(); j += 1 {
+				for k := 0; k < Q.Size(); k += 1 {
+					p, _ := P.At(j)
+					q, _ := Q.At(k)
+					R.Add(add(p.(State), q.(State)))
+				}
+			}
+
+			C.SetTransition(tupleName(x.p, x.q), a, R)
 		}
 	}
 
+	return C, pairs
+}
+
+// words w over the domain of h with h(w) ∈ L(A)
+// the letters of the result are the keys of h
+func InverseHomomorphism(A Nfa, h map[Letter][]Letter) Nfa {
+	A = withoutEpsilons(A)
+
+	letters := make([]string, 0, len(h))
+	for a := range h {
+		letters = append(letters, string(a))
+	}
+	sort.Strings(letters)
+
+	B := NewNfa()
+	B.SetStates(A.States().Copy().(StateSet))
+	B.SetInitialStates(A.InitialStates().Copy().(StateSet))
+	B.SetFinalStates(A.FinalStates().Copy().(StateSet))
+
+	for _, a := range letters {
+		B.Alphabet().Add(Letter(a))
+	}
+
+	for i := 0; i < A.States().Size(); i += 1 {
+		q, _ := A.States().At(i)
+
+		for _, a := range letters {
+			R := set.NewSet(q)
+			for _, b := range h[Letter(a)] {
+				R_ := set.NewSet()
+				for j := 0; j < R.Size(); j += 1 {
+					r, _ := R.At(j)
+					R_ = set.Join(R_, A.Transition(r.(State), b))
+				}
+				R = R_
+			}
+			B.SetTransition(q.(State), Letter(a), R)
+		}
+	}
+
+	return B
+}
+
+// a fresh state becomes the only initial and final state.
+// it has epsilon transitions to the old initial states, and the old final states have epsilon transitions to it.
+// A is not changed, the result is built from a copy
+func KleeneStar(A Nfa) Nfa {
+	A = A.Copy()
+
+	var q0 State
+	for i := 0; ; i += 1 {
+		q0 = State(fmt.Sprint(i))
+		if A.States().Probe(q0) == false {
+			break
+		}
+	}
+
+	A.States().Add(q0)
+
+	for i := 0; i < A.FinalStates().Size(); i += 1 {
+		q, _ := A.FinalStates().At(i)
+		A.SetEpsilonTransition(q.(State), set.Join(A.EpsilonTransition(q.(State)), set.NewSet(q0)))
+	}
+
+	A.SetEpsilonTransition(q0, A.InitialStates().Copy().(StateSet))
+
 	A.InitialStates().Clear()
 	A.InitialStates().Add(q0)
 	A.FinalStates().Clear()
@@ -178,6 +419,25 @@ func KleeneStar(A Nfa) Nfa {
 	return A
 }
 
+// B\A: words w with vw ∈ L(A) for some v ∈ L(B)
+// the initial states become the states A can be in after reading a word of L(B)
+func LeftQuotient(A, B Nfa) Nfa {
+	// intersect only adds reachable pairs
+	C, pairs := intersect(A, B)
+
+	I := set.NewSet()
+	for i := 0; i < C.States().Size(); i += 1 {
+		r, _ := C.States().At(i)
+		if x := pairs[r.(State)]; B.FinalStates().Probe(x.q) == true {
+			I.Add(x.p)
+		}
+	}
+
+	D := A.Copy()
+	D.SetInitialStates(I)
+	return D
+}
+
 func OneLetter(a Letter) Nfa {
 	A := NewNfa()
 	q0 := State("0")
@@ -190,6 +450,152 @@ func OneLetter(a Letter) Nfa {
 	return A
 }
 
+// all prefixes of words in L(A)
+func Prefixes(A Nfa) Nfa {
+	B := A.Trim()
+	B.SetFinalStates(B.States().Copy().(StateSet))
+	return B
+}
+
+// the mirror images of all words in L(A)
+func Reverse(A Nfa) Nfa {
+	B := NewNfa()
+	B.SetStates(A.States().Copy().(StateSet))
+	B.SetAlphabet(A.Alphabet().Copy().(Alphabet))
+	B.SetInitialStates(A.FinalStates().Copy().(StateSet))
+	B.SetFinalStates(A.InitialStates().Copy().(StateSet))
+
+	for i := 0; i < A.States().Size(); i += 1 {
+		q_, _ := A.States().At(i)
+		q := q_.(State)
+
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
+			a_, _ := A.Alphabet().At(j)
+			a := a_.(Letter)
+
+			R := A.Transition(q, a)
+			for k := 0; k < R.Size(); k += 1 {
+				r, _ := R.At(k)
+				B.SetTransition(r.(State), a, set.Join(B.Transition(r.(State), a), set.NewSet(q)))
+			}
+		}
+
+		R := A.EpsilonTransition(q)
+		for k := 0; k < R.Size(); k += 1 {
+			r, _ := R.At(k)
+			B.SetEpsilonTransition(r.(State), set.Join(B.EpsilonTransition(r.(State)), set.NewSet(q)))
+		}
+	}
+
+	return B
+}
+
+// A/B: words w with wv ∈ L(A) for some v ∈ L(B)
+// the final states become the states from which A accepts a word of L(B)
+func RightQuotient(A, B Nfa) Nfa {
+	A_ := A.Copy()
+	A_.SetInitialStates(A.States().Copy().(StateSet))
+
+	// pairs from which a pair of final states is reachable
+	C, pairs := intersect(A_, B)
+	useful := C.Trim().States()
+
+	F := set.NewSet()
+	for i := 0; i < useful.Size(); i += 1 {
+		r, _ := useful.At(i)
+		if x := pairs[r.(State)]; B.InitialStates().Probe(x.q) == true {
+			F.Add(x.p)
+		}
+	}
+
+	D := A.Copy()
+	D.SetFinalStates(F)
+	return D
+}
+
+// interleavings of a word of L(A) and a word of L(B)
+// the state (p,q) stands for p in A and q in B. only reachable pairs are added.
+func Shuffle(A, B Nfa) Nfa {
+	A = withoutEpsilons(A)
+	B = withoutEpsilons(B)
+
+	C := NewNfa()
+
+	C.SetAlphabet(set.Join(A.Alphabet(), B.Alphabet()))
+
+	queue := make([]statePair, 0)
+
+	var add func(State, State) State
+	add = func(p, q State) State {
+		r := tupleName(p, q)
+		if C.States().Probe(r) == false {
+			C.States().Add(r)
+			if A.FinalStates().Probe(p) == true && B.FinalStates().Probe(q) == true {
+				C.FinalStates().Add(r)
+			}
+			queue = append(queue, statePair{p, q})
+		}
+		return r
+	}
+
+	for i := 0; i < A.InitialStates().Size(); i += 1 {
+		for j := 0; j < B.InitialStates().Size(); j += 1 {
+			p, _ := A.InitialStates().At(i)
+			q, _ := B.InitialStates().At(j)
+			C.InitialStates().Add(add(p.(State), q.(State)))
+		}
+	}
+
+	for len(queue) > 0 {
+		x := queue[0]
+		queue = queue[1:]
+
+		for i := 0; i < C.Alphabet().Size(); i += 1 {
+			a_, _ := C.Alphabet().At(i)
+			a := a_.(Letter)
+
+			R := set.NewSet()
+
+			P := A.Transition(x.p, a)
+			for j := 0; j < P.Size(); j += 1 {
+				p, _ := P.At(j)
+				R.Add(add(p.(State), x.q))
+			}
+
+			Q := B.Transition(x.q, a)
+			for j := 0; j < Q.Size(); j += 1 {
+				q, _ := Q.At(j)
+				R.Add(add(x.p, q.(State)))
+			}
+
+			C.SetTransition(tupleName(x.p, x.q), a, R)
+		}
+	}
+
+	return C
+}
+
+// all suffixes of words in L(A)
+func Suffixes(A Nfa) Nfa {
+	B := A.Trim()
+	B.SetInitialStates(B.States().Copy().(StateSet))
+	return B
+}
+
+// (L(A) \ L(B)) ∪ (L(B) \ L(A))
+func SymmetricDifference(A, B Nfa) Nfa {
+	return Union(Difference(A, B), Difference(B, A))
+}
+
+// like json.Unmarshal, but rejects unknown fields
+func UnmarshalStrict(b []byte) (Nfa, error) {
+	A, err := unmarshal(b, true)
+	if err != nil {
+		return nil, err
+	}
+	return A, nil
+}
+
 func Union(A, B Nfa) Nfa {
 	C := NewNfa()
 
@@ -220,6 +626,14 @@ func Union(A, B Nfa) Nfa {
 
 				C.SetTransition(ss, a.(Letter), SS)
 			}
+
+			S := T.EpsilonTransition(s.(State))
+			SS := set.NewSet()
+			for l := 0; l < S.Size(); l += 1 {
+				t, _ := S.At(l)
+				SS.Add(State(fmt.Sprint(k, t)))
+			}
+			C.SetEpsilonTransition(ss, SS)
 		}
 	}
 
@@ -229,11 +643,12 @@ func Union(A, B Nfa) Nfa {
 /*****************************************************************************/
 
 type simpleNfa struct {
-	states        StateSet
-	alphabet      Alphabet
-	initialStates StateSet
-	transitions   map[State]map[Letter]StateSet
-	finalStates   StateSet
+	states             StateSet
+	alphabet           Alphabet
+	initialStates      StateSet
+	transitions        map[State]map[Letter]StateSet
+	epsilonTransitions map[State]StateSet
+	finalStates        StateSet
 }
 
 func (A simpleNfa) Alphabet() Alphabet {
@@ -283,6 +698,10 @@ func (A simpleNfa) String() string {
 				ret += fmt.Sprintln(" ", s, "--", a, "-->", S)
 			}
 		}
+		s, _ := A.States().At(i)
+		if S := A.EpsilonTransition(s.(State)); S.Size() > 0 {
+			ret += fmt.Sprintln(" ", s, "-- ε -->", S)
+		}
 	}
 
 	return ret
@@ -290,181 +709,98 @@ func (A simpleNfa) String() string {
 
 func (A simpleNfa) MarshalJSON() ([]byte, error) {
 	type simpleNfaWithExportedFields struct {
-		States        StateSet
-		Alphabet      Alphabet
-		InitialStates StateSet
-		Transitions   map[State]map[Letter]StateSet
-		FinalStates   StateSet
+		States             StateSet
+		Alphabet           Alphabet
+		InitialStates      StateSet
+		Transitions        map[State]map[Letter]StateSet
+		EpsilonTransitions map[State]StateSet `json:",omitempty"`
+		FinalStates        StateSet
 	}
-	return json.Marshal(simpleNfaWithExportedFields{A.states, A.alphabet, A.initialStates, A.transitions, A.finalStates})
+	return json.Marshal(simpleNfaWithExportedFields{A.states, A.alphabet, A.initialStates, A.transitions, A.epsilonTransitions, A.finalStates})
 }
 
+// A is only changed if b is valid
 func (A *simpleNfa) UnmarshalJSON(b []byte) error {
-	type simpleNfaForUnmarshaling struct {
-		States        []State
-		Alphabet      []Letter
-		InitialStates []State
-		Transitions   map[string]map[string][]string //using Letter or State won't work here
-		FinalStates   []State
-	}
-
-	var B simpleNfaForUnmarshaling
-	if err := json.Unmarshal(b, &B); err != nil {
+	B, err := unmarshal(b, false)
+	if err != nil {
 		return err
 	}
-
-	states := set.NewSet()
-	alphabet := set.NewSet()
-	initialStates := set.NewSet()
-	transitions := make(map[State]map[Letter]StateSet)
-	finalStates := set.NewSet()
-
-	for _, s := range B.States {
-		states.Add(s)
-	}
-	for _, l := range B.Alphabet {
-		alphabet.Add(l)
-	}
-	for _, s := range B.InitialStates {
-		initialStates.Add(s)
-	}
-	for _, s := range B.FinalStates {
-		finalStates.Add(s)
-	}
-	for k, v := range B.Transitions {
-		for l, w := range v {
-			if _, ok := transitions[State(k)]; ok != true {
-				transitions[State(k)] = make(map[Letter]StateSet)
-			}
-			S := set.NewSet()
-			for _, s := range w {
-				S.Add(State(s))
-			}
-			transitions[State(k)][Letter(l)] = S
-		}
-	}
-
-	*A = simpleNfa{states, alphabet, initialStates, transitions, finalStates}
-
+	*A = *B
 	return nil
 }
 
-func (A simpleNfa) IsEqual(B Nfa) bool {
+func (A simpleNfa) Validate() error {
+	inconsistencies := make([]Inconsistency, 0)
+	reported := make(map[Inconsistency]bool)
 
-	var transitionCount func(simpleNfa) int
-	transitionCount = func(A simpleNfa) int {
-		ret := 0
-		for _, v := range A.transitions {
-			for _, w := range v {
-				ret += w.Size()
-			}
+	report := func(field, undeclared, name string) {
+		i := Inconsistency{field, undeclared, name}
+		if reported[i] == false {
+			reported[i] = true
+			inconsistencies = append(inconsistencies, i)
 		}
-		return ret
-	}
-
-	C := B.(*simpleNfa)
-
-	if A.States().Size() != C.States().Size() || A.InitialStates().Size() != C.InitialStates().Size() || A.FinalStates().Size() != C.FinalStates().Size() || A.Alphabet().IsEqual(C.Alphabet()) != true || transitionCount(A) != transitionCount(*C) {
-		return false
 	}
 
-	var incomingEdgesCount func(simpleNfa, State, Letter) int
-	incomingEdgesCount = func(C simpleNfa, q State, l Letter) int {
-		ret := 0
-		for _, v := range C.transitions {
-			for l_, w := range v {
-				if l_ == l && w.Probe(q) == true {
-					ret += 1
-				}
+	checkStates := func(field string, S StateSet) {
+		for i := 0; i < S.Size(); i += 1 {
+			s, _ := S.At(i)
+			if A.states.Probe(s) == false {
+				report(field, "state", string(s.(State)))
 			}
 		}
-		return ret
 	}
 
-	var outgoingEdgesCount func(simpleNfa, State, Letter) int
-	outgoingEdgesCount = func(C simpleNfa, q State, l Letter) int {
-		ret := 0
-		for l_, w := range C.transitions[q] {
-			if l_ == l {
-				ret += w.Size()
-			}
-		}
-		return ret
-	}
+	checkStates("InitialStates", A.initialStates)
+	checkStates("FinalStates", A.finalStates)
 
-	permutations := make([]map[State]State, 0)
+	sources := make([]string, 0, len(A.transitions))
+	for s := range A.transitions {
+		sources = append(sources, string(s))
+	}
+	sort.Strings(sources)
 
-	var generatePermutations func(int, StateSet, map[State]State)
-	generatePermutations = func(i int, U StateSet, m map[State]State) {
-		if U.Size() == 0 {
-			permutations = append(permutations, m)
-			return
+	for _, s := range sources {
+		if A.states.Probe(State(s)) == false {
+			report("Transitions", "state", s)
 		}
 
-		q_, _ := A.States().At(i)
-		q := q_.(State)
-
-		for j := 0; j < U.Size(); j += 1 {
-			u_, _ := U.At(j)
-			u := u_.(State)
-
-			for k := 0; k < A.Alphabet().Size(); k += 1 {
-				l_, _ := A.Alphabet().At(k)
-				l := l_.(Letter)
-
-				if incomingEdgesCount(A, q, l) != incomingEdgesCount(*C, u, l) || outgoingEdgesCount(A, q, l) != outgoingEdgesCount(*C, u, l) {
-					continue
-				}
-			}
+		letters := make([]string, 0, len(A.transitions[State(s)]))
+		for l := range A.transitions[State(s)] {
+			letters = append(letters, string(l))
+		}
+		sort.Strings(letters)
 
-			U_ := U.Copy().(StateSet)
-			U_.Remove(u)
-			m_ := make(map[State]State)
-			for k, v := range m {
-				m_[k] = v
+		for _, l := range letters {
+			if A.alphabet.Probe(Letter(l)) == false {
+				report("Transitions", "letter", l)
 			}
-			m_[q] = u
-			generatePermutations(i+1, U_, m_)
+			checkStates("Transitions", A.transitions[State(s)][Letter(l)])
 		}
 	}
 
-	generatePermutations(0, C.States(), make(map[State]State))
+	sources = make([]string, 0, len(A.epsilonTransitions))
+	for s := range A.epsilonTransitions {
+		sources = append(sources, string(s))
+	}
+	sort.Strings(sources)
 
-	var translateSet func(StateSet, map[State]State) StateSet
-	translateSet = func(Q StateSet, m map[State]State) StateSet {
-		U := set.NewSet()
-		for i := 0; i < Q.Size(); i += 1 {
-			q, _ := Q.At(i)
-			U.Add(m[q.(State)])
+	for _, s := range sources {
+		if A.states.Probe(State(s)) == false {
+			report("EpsilonTransitions", "state", s)
 		}
-		return U
+		checkStates("EpsilonTransitions", A.epsilonTransitions[State(s)])
 	}
 
-	for _, identify := range permutations {
-
-		//check initial and final states
-		if B.InitialStates().IsEqual(translateSet(A.InitialStates(), identify)) != true || B.FinalStates().IsEqual(translateSet(A.FinalStates(), identify)) != true {
-			continue
-		}
+	if len(inconsistencies) > 0 {
+		return ValidationError{inconsistencies}
+	} //else {
+	return nil
+	//}
+}
 
-		//check transitions
-		correct := true
-		for k, v := range A.transitions {
-			for l, w := range v {
-				if B.Transition(identify[k], l).IsEqual(translateSet(w, identify)) != true {
-					correct = false
-					break
-				}
-			}
-			if correct == false {
-				break
-			}
-		}
-		if correct == true {
-			return true
-		}
-	}
-	return false
+// isomorphism of the automata, see CanonicalForm
+func (A simpleNfa) IsEqual(B Nfa) bool {
+	return isomorphic(&A, B)
 }
 
 func (A simpleNfa) Transition(s State, l Letter) StateSet {
@@ -525,6 +861,47 @@ func (A *simpleNfa) SetTransitionFunction(delta func(State, Letter) StateSet) {
 	}
 }
 
+func (A simpleNfa) EpsilonTransition(s State) StateSet {
+	if S, ok := A.epsilonTransitions[s]; ok == true {
+		return S
+	} //else {
+	return set.NewSet()
+	//}
+}
+
+func (A *simpleNfa) SetEpsilonTransition(s State, S StateSet) {
+	if S.Size() == 0 {
+		delete(A.epsilonTransitions, s)
+	} else {
+		A.epsilonTransitions[s] = S
+	}
+}
+
+func (A simpleNfa) EpsilonClosure(S StateSet) StateSet {
+	return epsilonClosure(&A, S)
+}
+
+func (A simpleNfa) EliminateEpsilons() Nfa {
+	return eliminateEpsilons(&A)
+}
+
+func (A simpleNfa) Accepts(w []Letter) bool {
+	run, err := A.AcceptingRun(w)
+	return err == nil && run != nil
+}
+
+// returns nil if w is not accepted
+func (A simpleNfa) AcceptingRun(w []Letter) ([]State, error) {
+	return acceptingRun(&A, w)
+}
+
+// the alphabet is kept, even if some letters are not used anymore
+func (A simpleNfa) Trim() Nfa {
+	B := A.removeUselessParts()
+	B.SetAlphabet(A.Alphabet().Copy().(Alphabet))
+	return B
+}
+
 func (A simpleNfa) Copy() Nfa {
 	B := NewNfa()
 	B.SetStates(A.States().Copy().(StateSet))
@@ -537,6 +914,9 @@ func (A simpleNfa) Copy() Nfa {
 			B.SetTransition(k, l, w.Copy().(StateSet))
 		}
 	}
+	for k, w := range A.epsilonTransitions {
+		B.SetEpsilonTransition(k, w.Copy().(StateSet))
+	}
 
 	return B
 }
@@ -545,7 +925,7 @@ func (A simpleNfa) Copy() Nfa {
 func (A simpleNfa) inducedNfa(q State) Nfa {
 	B := NewNfa().(*simpleNfa)
 	B.initialStates = set.NewSet(q)
-	B.alphabet = A.Alphabet()
+	B.alphabet = A.Alphabet().Copy().(Alphabet)
 
 	var recurse func(State)
 	recurse = func(q State) {
@@ -567,6 +947,15 @@ func (A simpleNfa) inducedNfa(q State) Nfa {
 				recurse(r.(State))
 			}
 		}
+
+		if w, ok := A.epsilonTransitions[q]; ok == true {
+			B.epsilonTransitions[q] = w.Copy().(StateSet)
+
+			for i := 0; i < w.Size(); i += 1 {
+				r, _ := w.At(i)
+				recurse(r.(State))
+			}
+		}
 	}
 
 	recurse(q)
@@ -596,6 +985,12 @@ func (A simpleNfa) reachableStates() StateSet {
 				recurse(q_.(State))
 			}
 		}
+
+		Q := A.EpsilonTransition(q)
+		for j := 0; j < Q.Size(); j += 1 {
+			q_, _ := Q.At(j)
+			recurse(q_.(State))
+		}
 	}
 
 	for i := 0; i < A.InitialStates().Size(); i += 1 {
@@ -626,6 +1021,11 @@ func (A simpleNfa) removeUselessParts() Nfa {
 				}
 			}
 		}
+		for k, w := range A.epsilonTransitions {
+			if w.Probe(u) == true {
+				recurse(k)
+			}
+		}
 	}
 
 	//backward search for all states that can reach a reachable final state
@@ -652,6 +1052,339 @@ func (A simpleNfa) removeUselessParts() Nfa {
 			}
 		}
 	}
+	for k, w := range A.epsilonTransitions {
+		if usefulStates.Probe(k) == true {
+			B.SetEpsilonTransition(k, set.Intersect(usefulStates, w))
+		}
+	}
 
 	return B
 }
+
+// on-the-fly subset simulation
+// the returned run has len(w)+1 states plus one state per epsilon transition taken,
+// or is nil if w is not accepted
+func acceptingRun(A Nfa, w []Letter) ([]State, error) {
+	for i, a := range w {
+		if A.Alphabet().Probe(a) == false {
+			return nil, LetterError{a, i}
+		}
+	}
+
+	// how a state was reached in a step of the simulation
+	type origin struct {
+		predecessor State
+		initial     bool
+		epsilon     bool
+	}
+
+	// each step lists its states in the order they were reached
+	type step struct {
+		states  []State
+		origins map[State]origin
+	}
+
+	var add func(*step, State, origin)
+	add = func(x *step, q State, o origin) {
+		if _, ok := x.origins[q]; ok == false {
+			x.origins[q] = o
+			x.states = append(x.states, q)
+		}
+	}
+
+	var close func(*step)
+	close = func(x *step) {
+		for i := 0; i < len(x.states); i += 1 {
+			q := x.states[i]
+			Q := A.EpsilonTransition(q)
+			for j := 0; j < Q.Size(); j += 1 {
+				r, _ := Q.At(j)
+				add(x, r.(State), origin{q, false, true})
+			}
+		}
+	}
+
+	steps := make([]*step, len(w)+1)
+
+	steps[0] = &step{make([]State, 0), make(map[State]origin)}
+	for i := 0; i < A.InitialStates().Size(); i += 1 {
+		q, _ := A.InitialStates().At(i)
+		add(steps[0], q.(State), origin{"", true, false})
+	}
+	close(steps[0])
+
+	for i, a := range w {
+		steps[i+1] = &step{make([]State, 0), make(map[State]origin)}
+
+		for _, q := range steps[i].states {
+			Q := A.Transition(q, a)
+			for k := 0; k < Q.Size(); k += 1 {
+				r, _ := Q.At(k)
+				add(steps[i+1], r.(State), origin{q, false, false})
+			}
+		}
+
+		if len(steps[i+1].states) == 0 {
+			return nil, nil
+		}
+		close(steps[i+1])
+	}
+
+	var q State
+	found := false
+	for _, r := range steps[len(w)].states {
+		if A.FinalStates().Probe(r) == true {
+			q = r
+			found = true
+			break
+		}
+	}
+	if found == false {
+		return nil, nil
+	}
+
+	reversedRun := []State{q}
+	for i := len(w); ; {
+		o := steps[i].origins[q]
+		if o.initial == true {
+			break
+		}
+		if o.epsilon == false {
+			i -= 1
+		}
+		q = o.predecessor
+		reversedRun = append(reversedRun, q)
+	}
+
+	run := make([]State, len(reversedRun))
+	for i, r := range reversedRun {
+		run[len(run)-1-i] = r
+	}
+
+	return run, nil
+}
+
+func epsilonClosure(A Nfa, S StateSet) StateSet {
+	closure := S.Copy().(StateSet)
+
+	for i := 0; i < closure.Size(); i += 1 {
+		q, _ := closure.At(i)
+		Q := A.EpsilonTransition(q.(State))
+		for j := 0; j < Q.Size(); j += 1 {
+			r, _ := Q.At(j)
+			closure.Add(r)
+		}
+	}
+
+	return closure
+}
+
+// q --a--> r, if r is reachable from q by epsilon transitions, one a transition and epsilon transitions.
+// q becomes final if a final state is reachable by epsilon transitions.
+func eliminateEpsilons(A Nfa) Nfa {
+	B := NewNfa()
+	B.SetStates(A.States().Copy().(StateSet))
+	B.SetAlphabet(A.Alphabet().Copy().(Alphabet))
+	B.SetInitialStates(A.InitialStates().Copy().(StateSet))
+
+	for i := 0; i < A.States().Size(); i += 1 {
+		q, _ := A.States().At(i)
+		Q := A.EpsilonClosure(set.NewSet(q))
+
+		if set.Intersect(Q, A.FinalStates()).Size() > 0 {
+			B.FinalStates().Add(q)
+		}
+
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
+			a, _ := A.Alphabet().At(j)
+
+			R := set.NewSet()
+			for k := 0; k < Q.Size(); k += 1 {
+				r, _ := Q.At(k)
+				R = set.Join(R, A.Transition(r.(State), a.(Letter)))
+			}
+
+			B.SetTransition(q.(State), a.(Letter), A.EpsilonClosure(R))
+		}
+	}
+
+	return B
+}
+
+// for algorithms that only follow letter transitions
+func withoutEpsilons(A Nfa) Nfa {
+	if HasEpsilonTransitions(A) == true {
+		return A.EliminateEpsilons()
+	} //else {
+	return A
+	//}
+}
+
+// subset construction over sigma
+// the result is deterministic and complete. its states are named "0", "1", ... in breadth first order,
+// the empty subset is always added as sink state.
+func determinize(A Nfa, sigma Alphabet) Nfa {
+	if C, ok := A.(*compactNfa); ok == true {
+		return C.determinize(sigma)
+	}
+
+	B := NewNfa()
+	B.SetAlphabet(sigma.Copy().(Alphabet))
+
+	names := make(map[string]State)
+	queue := make([]StateSet, 0)
+
+	var add func(StateSet) State
+	add = func(S StateSet) State {
+		k := subsetKey(S)
+		if q, ok := names[k]; ok == true {
+			return q
+		}
+
+		q := State(fmt.Sprint(len(names)))
+		names[k] = q
+
+		B.States().Add(q)
+		if set.Intersect(S, A.FinalStates()).Size() > 0 {
+			B.FinalStates().Add(q)
+		}
+
+		queue = append(queue, S)
+		return q
+	}
+
+	var processQueue func()
+	processQueue = func() {
+		for len(queue) > 0 {
+			S := queue[0]
+			queue = queue[1:]
+			q := add(S)
+
+			for i := 0; i < sigma.Size(); i += 1 {
+				a, _ := sigma.At(i)
+
+				T := set.NewSet()
+				for j := 0; j < S.Size(); j += 1 {
+					s, _ := S.At(j)
+					T = set.Join(T, A.Transition(s.(State), a.(Letter)))
+				}
+
+				B.SetTransition(q, a.(Letter), set.NewSet(add(A.EpsilonClosure(T))))
+			}
+		}
+	}
+
+	B.InitialStates().Add(add(A.EpsilonClosure(A.InitialStates())))
+	processQueue()
+
+	add(set.NewSet())
+	processQueue()
+
+	return B
+}
+
+// sorted and quoted state names, so that equal subsets get equal keys.
+// quoting keeps {"a b"} and {"a", "b"} apart.
+func subsetKey(S StateSet) string {
+	return fmt.Sprintf("%q", sortedStates(S))
+}
+
+// a state of a product construction, p from the first and q from the second automaton
+type statePair struct {
+	p, q State
+}
+
+// the name of the tuple (x1,...,xn). backslashes and commas in the components are escaped,
+// so that different tuples get different names.
+func tupleName(xs ...interface{}) State {
+	ret := "("
+	for i, x := range xs {
+		if i > 0 {
+			ret += ","
+		}
+		ret += tupleEscaper.Replace(fmt.Sprint(x))
+	}
+	return State(ret + ")")
+}
+
+var tupleEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`)
+
+func sortedStates(S StateSet) []string {
+	names := make([]string, S.Size())
+	for i := 0; i < S.Size(); i += 1 {
+		s, _ := S.At(i)
+		names[i] = string(s.(State))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// decodes and validates a marshaled simpleNfa
+func unmarshal(b []byte, strict bool) (*simpleNfa, error) {
+	type simpleNfaForUnmarshaling struct {
+		States             []State
+		Alphabet           []Letter
+		InitialStates      []State
+		Transitions        map[string]map[string][]string //using Letter or State won't work here
+		EpsilonTransitions map[string][]string
+		FinalStates        []State
+	}
+
+	var B simpleNfaForUnmarshaling
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	if strict == true {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(&B); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after the top-level value")
+	}
+
+	states := set.NewSet()
+	alphabet := set.NewSet()
+	initialStates := set.NewSet()
+	transitions := make(map[State]map[Letter]StateSet)
+	epsilonTransitions := make(map[State]StateSet)
+	finalStates := set.NewSet()
+
+	for _, s := range B.States {
+		states.Add(s)
+	}
+	for _, l := range B.Alphabet {
+		alphabet.Add(l)
+	}
+	for _, s := range B.InitialStates {
+		initialStates.Add(s)
+	}
+	for _, s := range B.FinalStates {
+		finalStates.Add(s)
+	}
+	for k, v := range B.Transitions {
+		for l, w := range v {
+			if _, ok := transitions[State(k)]; ok != true {
+				transitions[State(k)] = make(map[Letter]StateSet)
+			}
+			S := set.NewSet()
+			for _, s := range w {
+				S.Add(State(s))
+			}
+			transitions[State(k)][Letter(l)] = S
+		}
+	}
+	for k, v := range B.EpsilonTransitions {
+		S := set.NewSet()
+		for _, s := range v {
+			S.Add(State(s))
+		}
+		epsilonTransitions[State(k)] = S
+	}
+
+	A := &simpleNfa{states, alphabet, initialStates, transitions, epsilonTransitions, finalStates}
+	if err := A.Validate(); err != nil {
+		return nil, err
+	}
+
+	return A, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/hydroo/gomochex/basic/set"
+	"strings"
 	"testing"
 )
 
@@ -43,15 +44,68 @@ func TestSimpleNfa(t *testing.T) {
 	compareNfaToMarshaledNfa(A, s, true, t, "")
 }
 
+func TestComplement(t *testing.T) {
+	sigma := Alphabet(set.NewSet(Letter("a"), Letter("b")))
+
+	// (a.b)*
+	A := KleeneStar(Concat(OneLetter("a"), OneLetter("b")))
+	B := Complement(A, sigma)
+
+	if B.Alphabet().IsEqual(sigma) != true {
+		t.Error(B.Alphabet())
+	}
+
+	for _, w := range [][]Letter{{}, {"a", "b"}, {"a", "b", "a", "b"}} {
+		if B.Accepts(w) != false {
+			t.Error(w)
+		}
+	}
+	for _, w := range [][]Letter{{"a"}, {"b"}, {"b", "a"}, {"a", "b", "a"}, {"a", "a", "b"}} {
+		if B.Accepts(w) != true {
+			t.Error(w)
+		}
+	}
+
+	// double complement
+	if equal, counterexample := Equivalent(A, Complement(B, sigma)); equal != true {
+		t.Error(counterexample)
+	}
+
+	// complement of the empty language
+	if equal, counterexample := Equivalent(Complement(NewNfa(), sigma), KleeneStar(Union(OneLetter("a"), OneLetter("b")))); equal != true {
+		t.Error(counterexample)
+	}
+
+	// the subsets {"a b"} and {"a", "b"} are different states
+	C := NewNfa()
+	json.Unmarshal([]byte(`{"States":["s","a b","a","b"],"Alphabet":["x","y"],"InitialStates":["s"],"Transitions":{"s":{"x":["a b"],"y":["a","b"]}},"FinalStates":["a b"]}`), &C)
+	D := Complement(C, C.Alphabet())
+	if D.Accepts([]Letter{"x"}) != false || D.Accepts([]Letter{"y"}) != true {
+		t.Error(D)
+	}
+}
+
+func TestDeterminize(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal([]byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["0","1"]}},"FinalStates":["1"]}`), &A)
+
+	for _, C := range []Nfa{A, ToCompactNfa(A)} {
+		B := determinize(C, C.Alphabet())
+		if s, _ := json.Marshal(B); string(s) != `{"States":["0","1","2"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"1":{"a":["1"]},"2":{"a":["2"]}},"FinalStates":["1"]}` {
+			t.Error(string(s))
+		}
+	}
+}
+
 func TestConcat(t *testing.T) {
 	A := Concat(OneLetter("a"), Union(OneLetter("π"), OneLetter("c"))).(*simpleNfa).removeUselessParts()
 
-	//       -- a --> o -- π --> □
-	//      /
-	// --> o
-	//      \
-	//       -- a --> o -- c --> □
-	s := []byte(`{"States":["0","1","2","3","4"],"Alphabet":["a","π","c"],"InitialStates":["0"],"Transitions":{"0":{"a":["1","3"]},"1":{"π":["2"]},"3":{"c":["4"]}},"FinalStates":["2","4"]}`)
+	//                 -- ε --> o -- π --> □
+	//                /
+	// --> o -- a --> o
+	//                \
+	//                 -- ε --> o -- c --> □
+	s := []byte(`{"States":["0","1","2","3","4","5"],"Alphabet":["a","π","c"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"2":{"π":["3"]},"4":{"c":["5"]}},"EpsilonTransitions":{"1":["2","4"]},"FinalStates":["3","5"]}`)
 	compareNfaToMarshaledNfa(A, s, true, t, "")
 }
 
@@ -59,30 +113,316 @@ func TestConcatNfa2(t *testing.T) {
 	a := Letter("a")
 	A := Concat(OneLetter(a), OneLetter(a)).(*simpleNfa).removeUselessParts()
 
-	s := []byte(`{"States":["0","1","2"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"1":{"a":["2"]}},"FinalStates":["2"]}`)
+	// --> o -- a --> o -- ε --> o -- a --> □
+	s := []byte(`{"States":["0","1","2","3"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"2":{"a":["3"]}},"EpsilonTransitions":{"1":["2"]},"FinalStates":["3"]}`)
 	compareNfaToMarshaledNfa(A, s, true, t, "")
 }
 
+func TestConcatEmptyWord(t *testing.T) {
+	// (a)*.(b.(c)*)
+	A := Concat(KleeneStar(OneLetter("a")), Concat(OneLetter("b"), KleeneStar(OneLetter("c"))))
+
+	for _, w := range [][]Letter{{"b"}, {"a", "b"}, {"b", "c"}, {"a", "a", "b", "c", "c"}} {
+		if A.Accepts(w) != true {
+			t.Error(w)
+		}
+	}
+	for _, w := range [][]Letter{{}, {"a"}, {"c"}, {"b", "a"}, {"a", "c"}} {
+		if A.Accepts(w) != false {
+			t.Error(w)
+		}
+	}
+}
+
+func TestDifference(t *testing.T) {
+	// (a+b)* \ (a)*
+	A := Difference(KleeneStar(Union(OneLetter("a"), OneLetter("b"))), KleeneStar(OneLetter("a")))
+
+	for _, w := range [][]Letter{{"b"}, {"a", "b"}, {"b", "a", "a"}} {
+		if A.Accepts(w) != true {
+			t.Error(w)
+		}
+	}
+	for _, w := range [][]Letter{{}, {"a"}, {"a", "a"}} {
+		if A.Accepts(w) != false {
+			t.Error(w)
+		}
+	}
+}
+
+//                 +-a-+
+//                 |   |
+//                 v   |
+// --> 0 -- ε --> 1 ---+ -- ε --> 2 -- b --> |3|
+var epsilonAStarB = []byte(`{"States":["0","1","2","3"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"1":{"a":["1"]},"2":{"b":["3"]}},"EpsilonTransitions":{"0":["1"],"1":["2"]},"FinalStates":["3"]}`)
+
+func TestEpsilonClosure(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal(epsilonAStarB, &A)
+
+	type test struct {
+		S, closure StateSet
+	}
+
+	tests := []test{
+		test{set.NewSet(State("0")), set.NewSet(State("0"), State("1"), State("2"))},
+		test{set.NewSet(State("1")), set.NewSet(State("1"), State("2"))},
+		test{set.NewSet(State("2"), State("3")), set.NewSet(State("2"), State("3"))},
+		test{set.NewSet(), set.NewSet()},
+	}
+
+	for k, x := range tests {
+		if closure := A.EpsilonClosure(x.S); closure.IsEqual(x.closure) != true {
+			t.Error("case ", k, " should:", x.closure, " is:", closure)
+		}
+	}
+}
+
+func TestEliminateEpsilons(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal(epsilonAStarB, &A)
+
+	s := []byte(`{"States":["0","1","2","3"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["1","2"],"b":["3"]},"1":{"a":["1","2"],"b":["3"]},"2":{"b":["3"]}},"FinalStates":["3"]}`)
+	compareNfaToMarshaledNfa(A.EliminateEpsilons(), s, true, t, "")
+
+	// epsilon transitions into final states
+	B := NewNfa()
+	json.Unmarshal([]byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"1":{"a":["1"]}},"EpsilonTransitions":{"0":["1"]},"FinalStates":["1"]}`), &B)
+	u := []byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"]},"1":{"a":["1"]}},"FinalStates":["0","1"]}`)
+	compareNfaToMarshaledNfa(B.EliminateEpsilons(), u, true, t, "")
+
+	if HasEpsilonTransitions(A) != true || HasEpsilonTransitions(A.EliminateEpsilons()) != false {
+		t.Error(A)
+	}
+}
+
+func TestEpsilonTransitions(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal(epsilonAStarB, &A)
+
+	for _, w := range [][]Letter{{"b"}, {"a", "b"}, {"a", "a", "a", "b"}} {
+		if A.Accepts(w) != true {
+			t.Error(w)
+		}
+	}
+	for _, w := range [][]Letter{{}, {"a"}, {"b", "a"}, {"b", "b"}} {
+		if A.Accepts(w) != false {
+			t.Error(w)
+		}
+	}
+
+	if run, err := A.AcceptingRun([]Letter{"a", "b"}); err != nil || fmt.Sprint(run) != "[0 1 1 2 3]" {
+		t.Error(run, err)
+	}
+
+	// the combinators respect epsilon transitions
+	for _, B := range []Nfa{Union(A, OneLetter("c")), KleeneStar(A.Copy()), Intersect(A, A)} {
+		if B.Accepts([]Letter{"a", "b"}) != true || B.Accepts([]Letter{"a"}) != false {
+			t.Error(B)
+		}
+	}
+	if B := Concat(A, OneLetter("c")); B.Accepts([]Letter{"a", "b", "c"}) != true || B.Accepts([]Letter{"a", "b"}) != false {
+		t.Error(B)
+	}
+
+	if equal, counterexample := Equivalent(A, A.EliminateEpsilons()); equal != true {
+		t.Error(counterexample)
+	}
+	if equal, counterexample := Equivalent(A, Concat(KleeneStar(OneLetter("a")), OneLetter("b"))); equal != true {
+		t.Error(counterexample)
+	}
+	if equal, counterexample := Equivalent(Complement(A, A.Alphabet()), Complement(A.EliminateEpsilons(), A.Alphabet())); equal != true {
+		t.Error(counterexample)
+	}
+
+	// trimming keeps epsilon transitions
+	compareNfaToMarshaledNfa(A.(*simpleNfa).removeUselessParts(), epsilonAStarB, true, t, "")
+}
+
+func TestHomomorphism(t *testing.T) {
+	// (a.b)* with a -> c.c, b -> ε
+	A := Homomorphism(KleeneStar(Concat(OneLetter("a"), OneLetter("b"))), map[Letter][]Letter{"a": {"c", "c"}, "b": {}})
+
+	if equal, counterexample := Equivalent(A, KleeneStar(Concat(OneLetter("c"), OneLetter("c")))); equal != true {
+		t.Error(counterexample)
+	}
+
+	// letters without image are kept
+	B := Homomorphism(Concat(OneLetter("a"), OneLetter("b")), map[Letter][]Letter{"a": {"b", "c", "d"}})
+	if words := Words(B, 5); fmt.Sprint(words) != "[[b c d b]]" {
+		t.Error(words)
+	}
+	if B.Alphabet().IsEqual(set.NewSet(Letter("b"), Letter("c"), Letter("d"))) != true {
+		t.Error(B.Alphabet())
+	}
+
+	// the chain state does not merge with the existing state (p,a,q,0)
+	C := NewNfa()
+	json.Unmarshal([]byte(`{"States":["p","q","(p,a,q,0)"],"Alphabet":["a"],"InitialStates":["p","(p,a,q,0)"],"Transitions":{"p":{"a":["q"]}},"FinalStates":["q"]}`), &C)
+	D := Homomorphism(C, map[Letter][]Letter{"a": {"b", "c"}})
+	if words := Words(D, 5); fmt.Sprint(words) != "[[b c]]" || D.States().Size() != 4 || D.States().Probe(State("(p,a,q,0)'")) != true {
+		t.Error(D)
+	}
+}
+
+func TestInfixes(t *testing.T) {
+	A := Infixes(Concat(OneLetter("a"), Concat(OneLetter("b"), OneLetter("c"))))
+
+	if words := Words(A, 10); fmt.Sprint(words) != "[[] [a] [b] [c] [a b] [b c] [a b c]]" {
+		t.Error(words)
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	// (a.(a+b))* ∩ ((a+b).b)* = (a.b)*
+	A := KleeneStar(Concat(OneLetter("a"), Union(OneLetter("a"), OneLetter("b"))))
+	B := KleeneStar(Concat(Union(OneLetter("a"), OneLetter("b")), OneLetter("b")))
+	C := Intersect(A, B)
+
+	if equal, counterexample := Equivalent(C, KleeneStar(Concat(OneLetter("a"), OneLetter("b")))); equal != true {
+		t.Error(counterexample)
+	}
+
+	// -> (0,00) -a-> (f,0f)
+	//
+	// -> (0,10)
+	D := Intersect(OneLetter("a"), Union(OneLetter("a"), OneLetter("b")))
+	s := []byte(`{"States":["(0,00)","(0,10)","(f,0f)"],"Alphabet":["a","b"],"InitialStates":["(0,00)","(0,10)"],"Transitions":{"(0,00)":{"a":["(f,0f)"]}},"FinalStates":["(f,0f)"]}`)
+	compareNfaToMarshaledNfa(D, s, true, t, "")
+
+	if empty := Intersect(OneLetter("a"), OneLetter("b")); empty.FinalStates().Size() != 0 {
+		t.Error(empty)
+	}
+
+	// ("a,b","c") and ("a","b,c") are different pairs
+	E := NewNfa()
+	json.Unmarshal([]byte(`{"States":["a","a,b"],"Alphabet":["x"],"InitialStates":["a","a,b"],"Transitions":{},"FinalStates":["a"]}`), &E)
+	F := NewNfa()
+	json.Unmarshal([]byte(`{"States":["c","b,c"],"Alphabet":["x"],"InitialStates":["c","b,c"],"Transitions":{},"FinalStates":["b,c"]}`), &F)
+	G := Intersect(E, F)
+	if G.States().Size() != 4 || G.FinalStates().IsEqual(set.NewSet(State(`(a,b\,c)`))) != true || G.Accepts([]Letter{}) != true {
+		t.Error(G)
+	}
+	if H := Intersect(G, G); H.States().Size() != 16 || H.Accepts([]Letter{}) != true {
+		t.Error(H)
+	}
+	if H := LeftQuotient(E, F); H.InitialStates().IsEqual(set.NewSet(State("a"), State("a,b"))) != true {
+		t.Error(H)
+	}
+}
+
 func TestKleeneStar(t *testing.T) {
 	a := Letter("a")
 	b := Letter("π")
 	A := KleeneStar(Union(Concat(OneLetter(a), OneLetter(a)), Concat(OneLetter(b), OneLetter(b)))).(*simpleNfa).removeUselessParts()
 
-	// minimal nfa:
-	//
-	//   +-- a ---
-	//   |        \
-	//   | - a -> o
+	//   +------------------ ε ------------------
+	//   |                                       \
+	//   | - ε -> o - a -> o - ε -> o - a -> o --
 	//   v/
 	// ->□
 	//   ^\
-	//   | - π -> o
-	//   |       /
-	//   +-- π --
-	s := []byte(`{"States":["0","1","2"],"Alphabet":["a","π"],"InitialStates":["0"],"Transitions":{"0":{"a":["1"],"π":["2"]},"1":{"a":["0"]},"2":{"π":["0"]}},"FinalStates":["0"]}`)
+	//   | - ε -> o - π -> o - ε -> o - π -> o --
+	//   |                                       /
+	//   +------------------ ε ------------------
+	s := []byte(`{"States":["0","1","2","3","4","5","6","7","8"],"Alphabet":["a","π"],"InitialStates":["0"],"Transitions":{"1":{"a":["2"]},"3":{"a":["4"]},"5":{"π":["6"]},"7":{"π":["8"]}},"EpsilonTransitions":{"0":["1","5"],"2":["3"],"4":["0"],"6":["7"],"8":["0"]},"FinalStates":["0"]}`)
 	compareNfaToMarshaledNfa(A, s, true, t, "")
 }
 
+// (a)*.(a)* reuses the sub-automaton (a)*
+func TestKleeneStarReuse(t *testing.T) {
+	A := KleeneStar(OneLetter("a"))
+	B := Concat(A, A)
+
+	if equal, counterexample := Equivalent(A, KleeneStar(OneLetter("a"))); equal != true {
+		t.Error(counterexample)
+	}
+	if equal, counterexample := Equivalent(B, A); equal != true {
+		t.Error(counterexample)
+	}
+	if equal, counterexample := Equivalent(KleeneStar(A), A); equal != true {
+		t.Error(counterexample)
+	}
+}
+
+// the combinators neither change their arguments nor share sets with them
+func TestCombinatorsDoNotMutate(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal(epsilonAStarB, &A)
+	B := Concat(OneLetter("a"), KleeneStar(OneLetter("b")))
+	C := ToCompactNfa(B)
+
+	h := map[Letter][]Letter{"a": {"b", "b"}, "b": {}}
+
+	operations := map[string]func(Nfa, Nfa) Nfa{
+		"Complement":          func(X, Y Nfa) Nfa { return Complement(X, Y.Alphabet()) },
+		"Concat":              Concat,
+		"Difference":          Difference,
+		"EliminateEpsilons":   func(X, Y Nfa) Nfa { return X.EliminateEpsilons() },
+		"Homomorphism":        func(X, Y Nfa) Nfa { return Homomorphism(X, h) },
+		"Infixes":             func(X, Y Nfa) Nfa { return Infixes(X) },
+		"Intersect":           Intersect,
+		"InverseHomomorphism": func(X, Y Nfa) Nfa { return InverseHomomorphism(X, h) },
+		"KleeneStar":          func(X, Y Nfa) Nfa { return KleeneStar(X) },
+		"LeftQuotient":        LeftQuotient,
+		"Prefixes":            func(X, Y Nfa) Nfa { return Prefixes(X) },
+		"Reverse":             func(X, Y Nfa) Nfa { return Reverse(X) },
+		"RightQuotient":       RightQuotient,
+		"Shuffle":             Shuffle,
+		"Suffixes":            func(X, Y Nfa) Nfa { return Suffixes(X) },
+		"SymmetricDifference": SymmetricDifference,
+		"Trim":                func(X, Y Nfa) Nfa { return X.Trim() },
+		"Union":               Union,
+	}
+
+	for name, operation := range operations {
+		for _, X := range []Nfa{A, B, C} {
+			for _, Y := range []Nfa{A, B, C} {
+				x, _ := json.Marshal(X)
+				y, _ := json.Marshal(Y)
+
+				Z := operation(X, Y)
+
+				// changes of the result must not reach the arguments
+				Z.States().Add(State("new"))
+				Z.Alphabet().Add(Letter("new"))
+				Z.InitialStates().Add(State("new"))
+				Z.FinalStates().Clear()
+				for i := 0; i < Z.States().Size(); i += 1 {
+					q, _ := Z.States().At(i)
+					for j := 0; j < Z.Alphabet().Size(); j += 1 {
+						a, _ := Z.Alphabet().At(j)
+						Z.Transition(q.(State), a.(Letter)).Add(State("new"))
+					}
+					Z.EpsilonTransition(q.(State)).Add(State("new"))
+				}
+
+				if x_, _ := json.Marshal(X); string(x) != string(x_) {
+					t.Error(name, "changed its first argument\nbefore:", string(x), "\nafter: ", string(x_))
+				}
+				if y_, _ := json.Marshal(Y); string(y) != string(y_) {
+					t.Error(name, "changed its second argument\nbefore:", string(y), "\nafter: ", string(y_))
+				}
+			}
+		}
+	}
+}
+
+func TestLeftQuotient(t *testing.T) {
+	// (a.b.c + b.d) quotient by (a + a.b + b)
+	A := Union(Concat(OneLetter("a"), Concat(OneLetter("b"), OneLetter("c"))), Concat(OneLetter("b"), OneLetter("d")))
+	B := Union(Union(OneLetter("a"), Concat(OneLetter("a"), OneLetter("b"))), OneLetter("b"))
+
+	if words := Words(LeftQuotient(A, B), 10); fmt.Sprint(words) != "[[c] [d] [b c]]" {
+		t.Error(words)
+	}
+
+	// (a)* quotient by (a)* is (a)*
+	if equal, counterexample := Equivalent(LeftQuotient(KleeneStar(OneLetter("a")), KleeneStar(OneLetter("a"))), KleeneStar(OneLetter("a"))); equal != true {
+		t.Error(counterexample)
+	}
+}
+
 func TestOneLetter(t *testing.T) {
 	a := Letter("π")
 	A := OneLetter(a)
@@ -91,6 +431,79 @@ func TestOneLetter(t *testing.T) {
 	compareNfaToMarshaledNfa(A, s, true, t, "")
 }
 
+func TestPrefixes(t *testing.T) {
+	// useless parts must not contribute prefixes
+	A := Prefixes(Union(Concat(OneLetter("a"), OneLetter("b")), Concat(OneLetter("c"), Intersect(OneLetter("a"), OneLetter("b")))))
+
+	if words := Words(A, 10); fmt.Sprint(words) != "[[] [a] [a b]]" {
+		t.Error(words)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal(epsilonAStarB, &A)
+
+	// b.(a)*
+	if equal, counterexample := Equivalent(Reverse(A), Concat(OneLetter("b"), KleeneStar(OneLetter("a")))); equal != true {
+		t.Error(counterexample)
+	}
+
+	B := Concat(OneLetter("a"), Concat(OneLetter("b"), OneLetter("c")))
+	if words := Words(Reverse(B), 10); fmt.Sprint(words) != "[[c b a]]" {
+		t.Error(words)
+	}
+	if equal, counterexample := Equivalent(Reverse(Reverse(B)), B); equal != true {
+		t.Error(counterexample)
+	}
+}
+
+func TestRightQuotient(t *testing.T) {
+	// (a.b.c + b.d) quotient by (c + b.c + d)
+	A := Union(Concat(OneLetter("a"), Concat(OneLetter("b"), OneLetter("c"))), Concat(OneLetter("b"), OneLetter("d")))
+	B := Union(Union(OneLetter("c"), Concat(OneLetter("b"), OneLetter("c"))), OneLetter("d"))
+
+	if words := Words(RightQuotient(A, B), 10); fmt.Sprint(words) != "[[a] [b] [a b]]" {
+		t.Error(words)
+	}
+
+	// (a.a)* quotient by a is a.(a.a)*
+	if equal, counterexample := Equivalent(RightQuotient(KleeneStar(Concat(OneLetter("a"), OneLetter("a"))), OneLetter("a")), Concat(OneLetter("a"), KleeneStar(Concat(OneLetter("a"), OneLetter("a"))))); equal != true {
+		t.Error(counterexample)
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	A := Shuffle(Concat(OneLetter("a"), OneLetter("b")), OneLetter("c"))
+
+	if words := Words(A, 10); fmt.Sprint(words) != "[[a b c] [a c b] [c a b]]" {
+		t.Error(words)
+	}
+
+	// a shuffled with (b)* is (b)*.a.(b)*
+	B := Shuffle(OneLetter("a"), KleeneStar(OneLetter("b")))
+	if equal, counterexample := Equivalent(B, Concat(KleeneStar(OneLetter("b")), Concat(OneLetter("a"), KleeneStar(OneLetter("b"))))); equal != true {
+		t.Error(counterexample)
+	}
+}
+
+func TestSuffixes(t *testing.T) {
+	A := Suffixes(Concat(OneLetter("a"), Concat(OneLetter("b"), OneLetter("c"))))
+
+	if words := Words(A, 10); fmt.Sprint(words) != "[[] [c] [b c] [a b c]]" {
+		t.Error(words)
+	}
+}
+
+func TestSymmetricDifference(t *testing.T) {
+	// (a+b) ⊕ (b+c) = (a+c)
+	A := SymmetricDifference(Union(OneLetter("a"), OneLetter("b")), Union(OneLetter("b"), OneLetter("c")))
+
+	if equal, counterexample := Equivalent(A, Union(OneLetter("a"), OneLetter("c"))); equal != true {
+		t.Error(counterexample)
+	}
+}
+
 func TestUnion(t *testing.T) {
 	a := Letter("a")
 	b := Letter("π")
@@ -101,6 +514,30 @@ func TestUnion(t *testing.T) {
 	compareNfaToMarshaledNfa(A, s, true, t, "")
 }
 
+func TestInverseHomomorphism(t *testing.T) {
+	// h(x) = a.b, h(y) = c, h(z) = ε
+	h := map[Letter][]Letter{"x": {"a", "b"}, "y": {"c"}, "z": {}}
+
+	// (a.b)*.c
+	A := Concat(KleeneStar(Concat(OneLetter("a"), OneLetter("b"))), OneLetter("c"))
+	B := InverseHomomorphism(A, h)
+
+	for _, w := range [][]Letter{{"y"}, {"x", "y"}, {"z", "x", "z", "x", "y", "z"}} {
+		if B.Accepts(w) != true {
+			t.Error(w)
+		}
+	}
+	for _, w := range [][]Letter{{}, {"x"}, {"y", "x"}, {"z"}} {
+		if B.Accepts(w) != false {
+			t.Error(w)
+		}
+	}
+
+	if B.Alphabet().IsEqual(set.NewSet(Letter("x"), Letter("y"), Letter("z"))) != true {
+		t.Error(B.Alphabet())
+	}
+}
+
 func TestJson(t *testing.T) {
 	a := Letter("a")
 	q0 := State("0")
@@ -140,6 +577,91 @@ func TestJson(t *testing.T) {
 	}
 }
 
+func TestEpsilonJson(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal(epsilonAStarB, &A)
+
+	s, err := json.Marshal(A)
+	if err != nil || bytes.Compare(s, epsilonAStarB) != 0 {
+		t.Error(err, "\nshould:", string(epsilonAStarB), "\nis:    ", string(s))
+	}
+
+	if strings.Contains(A.String(), "1 -- ε --> [2]") != true {
+		t.Error(A)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	A := NewNfa()
+	if err := json.Unmarshal(epsilonAStarB, &A); err != nil || A.Validate() != nil {
+		t.Error(err, A.Validate())
+	}
+
+	invalid := []byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0","x"],"Transitions":{"0":{"a":["1","y"],"b":["1"]},"z":{"a":["0"]}},"EpsilonTransitions":{"1":["y"]},"FinalStates":["x"]}`)
+
+	B := NewNfa()
+	err := json.Unmarshal(invalid, &B)
+
+	e, ok := err.(ValidationError)
+	if ok == false {
+		t.Fatal(err)
+	}
+
+	expected := []Inconsistency{
+		Inconsistency{"InitialStates", "state", "x"},
+		Inconsistency{"FinalStates", "state", "x"},
+		Inconsistency{"Transitions", "state", "y"},
+		Inconsistency{"Transitions", "letter", "b"},
+		Inconsistency{"Transitions", "state", "z"},
+		Inconsistency{"EpsilonTransitions", "state", "y"},
+	}
+	if fmt.Sprint(e.Inconsistencies) != fmt.Sprint(expected) {
+		t.Error(e.Inconsistencies)
+	}
+	if e.Error() != "invalid nfa: undeclared state x in InitialStates, undeclared state x in FinalStates, undeclared state y in Transitions, undeclared letter b in Transitions, undeclared state z in Transitions, undeclared state y in EpsilonTransitions" {
+		t.Error(e)
+	}
+
+	// B is unchanged
+	if B.States().Size() != 0 {
+		t.Error(B)
+	}
+
+	// automata built with the setters can be validated too
+	C := OneLetter("a")
+	C.FinalStates().Add(State("q"))
+	if err, ok := C.Validate().(ValidationError); ok == false || len(err.Inconsistencies) != 1 {
+		t.Error(err)
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	A, err := UnmarshalStrict(epsilonAStarB)
+	if err != nil {
+		t.Error(err)
+	} else if s, _ := json.Marshal(A); bytes.Compare(s, epsilonAStarB) != 0 {
+		t.Error(string(s))
+	}
+
+	tests := []string{
+		`{"States":["0"],"Alphabet":[],"InitialStates":["0"],"Transitions":{},"FinalStates":["0"],"Comment":"x"}`,
+		`{"States":["0"],"Alphabet":[],"InitialStates":["0"],"Transitions":{},"FinalStates":["1"]}`,
+		`{"States":["0"],"Alphabet":[],"InitialStates":["0"],"Transitions":{},"FinalStates":["0"]} {}`,
+		`{"States":["0"]`,
+	}
+	for k, x := range tests {
+		if A, err := UnmarshalStrict([]byte(x)); A != nil || err == nil {
+			t.Error("case ", k, A, err)
+		}
+	}
+
+	// unknown fields are ignored otherwise
+	B := NewNfa()
+	if err := json.Unmarshal([]byte(tests[0]), &B); err != nil || B.States().Size() != 1 {
+		t.Error(err, B)
+	}
+}
+
 func TestIsEqual(t *testing.T) {
 	type test struct {
 		s, u          []byte
@@ -175,6 +697,14 @@ func TestIsEqual(t *testing.T) {
 			[]byte(`{"States":["0","1","2","3"],"Alphabet":["a","π"],"InitialStates":["0","2"],"Transitions":{"0":{"a":["1"]},"2":{"π":["3"]}},"FinalStates":["1","3"]}`),
 			[]byte(`{"States":["0","1","2","3"],"Alphabet":["a","π"],"InitialStates":["0","1"],"Transitions":{"0":{"a":["1"]},"2":{"π":["3"]}},"FinalStates":["1","3"]}`),
 			false},
+		test{
+			[]byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"1":{"a":["1"]}},"EpsilonTransitions":{"0":["1"]},"FinalStates":["1"]}`),
+			[]byte(`{"States":["x","y"],"Alphabet":["a"],"InitialStates":["y"],"Transitions":{"x":{"a":["x"]}},"EpsilonTransitions":{"y":["x"]},"FinalStates":["x"]}`),
+			true},
+		test{
+			[]byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"1":{"a":["1"]}},"EpsilonTransitions":{"0":["1"]},"FinalStates":["1"]}`),
+			[]byte(`{"States":["0","1"],"Alphabet":["a"],"InitialStates":["0"],"Transitions":{"1":{"a":["1"]}},"EpsilonTransitions":{"1":["0"]},"FinalStates":["1"]}`),
+			false},
 	}
 
 	for k, x := range tests {
@@ -182,6 +712,64 @@ func TestIsEqual(t *testing.T) {
 	}
 }
 
+func TestAccepts(t *testing.T) {
+	// (a.(b+π))*
+	A := KleeneStar(Concat(OneLetter("a"), Union(OneLetter("b"), OneLetter("π"))))
+
+	type test struct {
+		w                []Letter
+		shouldBeAccepted bool
+	}
+
+	tests := []test{
+		test{[]Letter{}, true},
+		test{[]Letter{"a", "b"}, true},
+		test{[]Letter{"a", "π", "a", "b"}, true},
+		test{[]Letter{"a"}, false},
+		test{[]Letter{"b", "a"}, false},
+		test{[]Letter{"a", "b", "a"}, false},
+	}
+
+	for k, x := range tests {
+		if A.Accepts(x.w) != x.shouldBeAccepted {
+			t.Error("case ", k, x.w)
+		}
+
+		run, err := A.AcceptingRun(x.w)
+		if err != nil || (run != nil) != x.shouldBeAccepted {
+			t.Error("case ", k, x.w, run, err)
+			continue
+		}
+		if run == nil {
+			continue
+		}
+
+		if A.InitialStates().Probe(run[0]) != true || A.FinalStates().Probe(run[len(run)-1]) != true {
+			t.Error("case ", k, x.w, run)
+		}
+		// every step reads the next letter or takes an epsilon transition
+		i := 0
+		for j := 0; j+1 < len(run); j += 1 {
+			if i < len(x.w) && A.Transition(run[j], x.w[i]).Probe(run[j+1]) == true {
+				i += 1
+			} else if A.EpsilonTransition(run[j]).Probe(run[j+1]) != true {
+				t.Error("case ", k, x.w, run)
+			}
+		}
+		if i != len(x.w) {
+			t.Error("case ", k, x.w, run)
+		}
+	}
+
+	// letter not in the alphabet
+	if A.Accepts([]Letter{"a", "c"}) != false {
+		t.Error()
+	}
+	if run, err := A.AcceptingRun([]Letter{"a", "c"}); run != nil || err != (LetterError{"c", 1}) {
+		t.Error(run, err)
+	}
+}
+
 func TestInducedNfa(t *testing.T) {
 	// -> o -> o -> □
 	//    |    ^    |
@@ -0,0 +1,179 @@
+package nfa
+
+import (
+	"fmt"
+	"github.com/hydroo/gomochex/basic/set"
+	"math"
+	"math/rand"
+)
+
+// Tabakov and Vardi's model of random automata:
+// n states "0", ..., "n-1", of which "0" is initial.
+// for every letter round(transitionDensity*n) distinct transitions are chosen uniformly,
+// round(acceptanceDensity*n) distinct final states are chosen uniformly.
+// the same rng state gives the same automaton.
+func Random(n int, sigma Alphabet, transitionDensity, acceptanceDensity float64, rng *rand.Rand) Nfa {
+	return random(n, sigma, transitionDensity, acceptanceDensity, false, rng)
+}
+
+// like Random, but every source state has at most one transition per letter.
+// hence transitionDensity is at most 1.
+func RandomDeterministic(n int, sigma Alphabet, transitionDensity, acceptanceDensity float64, rng *rand.Rand) Nfa {
+	return random(n, sigma, transitionDensity, acceptanceDensity, true, rng)
+}
+
+// like Random, but every state is reachable and reaches a final state, hence the result has n states.
+// in the order of the states, an unreachable state gets a transition from a random reachable state,
+// then a state which cannot reach a final state gets a transition to a random state which can,
+// each for a random letter. if there is no final state, a random state becomes final.
+// for an empty alphabet only "0" remains.
+func RandomTrim(n int, sigma Alphabet, transitionDensity, acceptanceDensity float64, rng *rand.Rand) Nfa {
+	A := Random(n, sigma, transitionDensity, acceptanceDensity, rng)
+	if n <= 0 {
+		return A
+	}
+
+	states := make([]State, n)
+	index := make(map[State]int)
+	for i := 0; i < n; i += 1 {
+		states[i] = State(fmt.Sprint(i))
+		index[states[i]] = i
+	}
+
+	if A.FinalStates().Size() == 0 {
+		A.FinalStates().Add(states[rng.Intn(n)])
+	}
+	if sigma.Size() == 0 {
+		return A.Trim()
+	}
+
+	successors := make([][]int, n)
+	predecessors := make([][]int, n)
+	for i, p := range states {
+		for k := 0; k < sigma.Size(); k += 1 {
+			a, _ := sigma.At(k)
+			R := A.Transition(p, a.(Letter))
+			for l := 0; l < R.Size(); l += 1 {
+				r, _ := R.At(l)
+				j := index[r.(State)]
+				successors[i] = append(successors[i], j)
+				predecessors[j] = append(predecessors[j], i)
+			}
+		}
+	}
+
+	addTransition := func(i, j int) {
+		a, _ := sigma.At(rng.Intn(sigma.Size()))
+		R := A.Transition(states[i], a.(Letter)).Copy().(StateSet)
+		R.Add(states[j])
+		A.SetTransition(states[i], a.(Letter), R)
+		successors[i] = append(successors[i], j)
+		predecessors[j] = append(predecessors[j], i)
+	}
+
+	// marks everything found from start along edges, and links every other state to a random marked state
+	connect := func(start []int, edges [][]int, link func(marked, unmarked int)) {
+		marked := make([]bool, n)
+		list := make([]int, 0, n)
+
+		visit := func(i int) {
+			stack := []int{i}
+			for len(stack) > 0 {
+				j := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if marked[j] == true {
+					continue
+				}
+				marked[j] = true
+				list = append(list, j)
+				stack = append(stack, edges[j]...)
+			}
+		}
+
+		for _, i := range start {
+			visit(i)
+		}
+		for i := 0; i < n; i += 1 {
+			if marked[i] == false {
+				link(list[rng.Intn(len(list))], i)
+				visit(i)
+			}
+		}
+	}
+
+	connect([]int{0}, successors, func(marked, unmarked int) { addTransition(marked, unmarked) })
+
+	final := make([]int, 0)
+	for i, q := range states {
+		if A.FinalStates().Probe(q) == true {
+			final = append(final, i)
+		}
+	}
+	connect(final, predecessors, func(marked, unmarked int) { addTransition(unmarked, marked) })
+
+	return A
+}
+
+func random(n int, sigma Alphabet, transitionDensity, acceptanceDensity float64, deterministic bool, rng *rand.Rand) Nfa {
+	A := NewNfa()
+	A.SetAlphabet(sigma.Copy().(Alphabet))
+
+	if n <= 0 {
+		return A
+	}
+
+	states := make([]State, n)
+	for i := 0; i < n; i += 1 {
+		states[i] = State(fmt.Sprint(i))
+		A.States().Add(states[i])
+	}
+	A.InitialStates().Add(states[0])
+
+	count := func(density float64, max int) int {
+		k := int(math.Floor(density*float64(n) + 0.5))
+		if k < 0 {
+			return 0
+		} else if k > max {
+			return max
+		}
+		return k
+	}
+
+	for _, i := range sample(n, count(acceptanceDensity, n), rng) {
+		A.FinalStates().Add(states[i])
+	}
+
+	for j := 0; j < sigma.Size(); j += 1 {
+		a, _ := sigma.At(j)
+
+		if deterministic == true {
+			for _, p := range sample(n, count(transitionDensity, n), rng) {
+				A.SetTransition(states[p], a.(Letter), set.NewSet(states[rng.Intn(n)]))
+			}
+		} else {
+			for _, t := range sample(n*n, count(transitionDensity, n*n), rng) {
+				p, q := states[t/n], states[t%n]
+				Q := A.Transition(p, a.(Letter)).Copy().(StateSet)
+				Q.Add(q)
+				A.SetTransition(p, a.(Letter), Q)
+			}
+		}
+	}
+
+	return A
+}
+
+// k distinct numbers from 0, ..., m-1, chosen uniformly with Floyd's algorithm
+func sample(m, k int, rng *rand.Rand) []int {
+	chosen := make(map[int]bool)
+	ret := make([]int, 0, k)
+	for j := m - k; j < m; j += 1 {
+		t := rng.Intn(j + 1)
+		if chosen[t] == true {
+			t = j
+		}
+		chosen[t] = true
+		ret = append(ret, t)
+	}
+	return ret
+}
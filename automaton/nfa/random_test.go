@@ -0,0 +1,112 @@
+package nfa
+
+import (
+	"encoding/json"
+	"github.com/hydroo/gomochex/basic/set"
+	"math/rand"
+	"testing"
+)
+
+func TestRandom(t *testing.T) {
+	sigma := set.NewSet(Letter("a"), Letter("b"))
+
+	for seed := int64(0); seed < 20; seed += 1 {
+		A := Random(10, sigma, 1.25, 0.5, rand.New(rand.NewSource(seed)))
+		B := Random(10, sigma, 1.25, 0.5, rand.New(rand.NewSource(seed)))
+
+		// reproducible
+		s, _ := json.Marshal(A)
+		u, _ := json.Marshal(B)
+		if string(s) != string(u) {
+			t.Error(seed, string(s), string(u))
+		}
+
+		if A.States().Size() != 10 || A.InitialStates().IsEqual(set.NewSet(State("0"))) != true || A.FinalStates().Size() != 5 || A.Validate() != nil {
+			t.Error(seed, A)
+		}
+
+		for _, a := range []Letter{"a", "b"} {
+			count := 0
+			for i := 0; i < A.States().Size(); i += 1 {
+				q, _ := A.States().At(i)
+				count += A.Transition(q.(State), a).Size()
+			}
+			if count != 13 {
+				t.Error(seed, a, count)
+			}
+		}
+	}
+
+	// densities beyond the number of possible transitions and final states
+	A := Random(3, sigma, 5, 2, rand.New(rand.NewSource(0)))
+	if A.FinalStates().Size() != 3 || transitionCount(A) != 18 {
+		t.Error(A)
+	}
+
+	if B := Random(0, sigma, 1, 1, rand.New(rand.NewSource(0))); B.States().Size() != 0 {
+		t.Error(B)
+	}
+}
+
+func TestRandomDeterministic(t *testing.T) {
+	sigma := set.NewSet(Letter("a"), Letter("b"), Letter("c"))
+
+	for seed := int64(0); seed < 20; seed += 1 {
+		A := RandomDeterministic(8, sigma, 0.75, 0.25, rand.New(rand.NewSource(seed)))
+
+		if A.FinalStates().Size() != 2 || transitionCount(A) != 18 {
+			t.Error(seed, A)
+		}
+		for i := 0; i < A.States().Size(); i += 1 {
+			for j := 0; j < sigma.Size(); j += 1 {
+				q, _ := A.States().At(i)
+				a, _ := sigma.At(j)
+				if A.Transition(q.(State), a.(Letter)).Size() > 1 {
+					t.Error(seed, A)
+				}
+			}
+		}
+	}
+}
+
+func TestRandomTrim(t *testing.T) {
+	sigma := set.NewSet(Letter("a"), Letter("b"))
+
+	type test struct {
+		n                                    int
+		transitionDensity, acceptanceDensity float64
+	}
+
+	// sparse automata, where Random is rarely trim
+	tests := []test{
+		test{10, 1.5, 0.2},
+		test{10, 0.5, 0.1},
+		test{25, 0, 0},
+		test{1, 0, 0},
+	}
+
+	for k, x := range tests {
+		for seed := int64(0); seed < 20; seed += 1 {
+			A := Random(x.n, sigma, x.transitionDensity, x.acceptanceDensity, rand.New(rand.NewSource(seed)))
+			B := RandomTrim(x.n, sigma, x.transitionDensity, x.acceptanceDensity, rand.New(rand.NewSource(seed)))
+			C := RandomTrim(x.n, sigma, x.transitionDensity, x.acceptanceDensity, rand.New(rand.NewSource(seed)))
+
+			s, _ := json.Marshal(B)
+			u, _ := json.Marshal(C)
+			if string(s) != string(u) || B.Alphabet().IsEqual(sigma) != true || B.Validate() != nil {
+				t.Error("case", k, seed, B)
+			}
+			if B.States().Size() != x.n || B.Trim().States().Size() != x.n {
+				t.Error("case", k, seed, B)
+			}
+			// Random's transitions and final states are kept, so its language is included
+			if included, w := Includes(A, B); included != true {
+				t.Error("case", k, seed, w)
+			}
+		}
+	}
+
+	if A := RandomTrim(5, set.NewSet(), 1, 1, rand.New(rand.NewSource(0))); A.States().Size() != 1 {
+		t.Error(A)
+	}
+}
@@ -0,0 +1,276 @@
+package nfa
+
+import (
+	"github.com/hydroo/gomochex/basic/set"
+)
+
+// direct forward simulation
+// q simulates p if q is final whenever p is, and q can answer every transition of p
+// with a transition for the same letter into a state which simulates p's successor.
+// the returned map contains for every state p all states which simulate p, including p itself.
+// epsilon transitions are eliminated beforehand.
+func ForwardSimulation(A Nfa) map[State]StateSet {
+	A = withoutEpsilons(A)
+
+	return simulationToMap(forwardSimulation(A))
+}
+
+// direct backward simulation
+// q simulates p if q is initial whenever p is, and q can answer every incoming transition of p
+// with an incoming transition for the same letter from a state which simulates p's predecessor.
+// the returned map contains for every state p all states which simulate p, including p itself.
+// epsilon transitions are eliminated beforehand.
+func BackwardSimulation(A Nfa) map[State]StateSet {
+	A = withoutEpsilons(A)
+
+	return simulationToMap(backwardSimulation(A))
+}
+
+// merges forward simulation equivalent states, then backward simulation equivalent states,
+// and finally removes transitions p --a--> r if there is a transition p --a--> r'
+// where r' simulates r, but not the other way round. initial states are pruned likewise.
+// epsilon transitions are eliminated beforehand, and useless states are removed.
+//
+// returns the number of removed states and transitions, counted after epsilon elimination.
+func ReduceBySimulation(A Nfa) (Nfa, int, int) {
+	A = withoutEpsilons(A)
+	B := A.Trim()
+
+	B = quotientBySimulation(B, forwardSimulation(B))
+	B = quotientBySimulation(B, backwardSimulation(B))
+	B = pruneBySimulation(B, forwardSimulation(B))
+	B = B.Trim()
+
+	return B, A.States().Size() - B.States().Size(), transitionCount(A) - transitionCount(B)
+}
+
+type simulationRelation struct {
+	states    []State
+	index     map[State]int
+	simulates [][]bool // simulates[i][j]: states[j] simulates states[i]
+}
+
+func (R simulationRelation) equivalent(i, j int) bool {
+	return R.simulates[i][j] == true && R.simulates[j][i] == true
+}
+
+// A has no epsilon transitions. compact automata are handled on bitsets
+func forwardSimulation(A Nfa) simulationRelation {
+	if C, ok := A.(*compactNfa); ok == true {
+		return C.simulation(C.successors, *C.finalStates)
+	}
+	return simulation(A, func(q State, a Letter) StateSet { return A.Transition(q, a) }, A.FinalStates())
+}
+
+// A has no epsilon transitions. compact automata are handled on bitsets
+func backwardSimulation(A Nfa) simulationRelation {
+	if C, ok := A.(*compactNfa); ok == true {
+		return C.simulation(C.predecessors(), *C.initialStates)
+	}
+	return simulation(A, predecessorFunction(A), A.InitialStates())
+}
+
+// greatest fixpoint
+// step(q, a) are the states reached from q with a, forward or backward.
+// in the beginning p is simulated by q if q ∈ S or p ∉ S.
+func simulation(A Nfa, step func(State, Letter) StateSet, S StateSet) simulationRelation {
+	n := A.States().Size()
+
+	R := simulationRelation{make([]State, n), make(map[State]int), make([][]bool, n)}
+	for i := 0; i < n; i += 1 {
+		q, _ := A.States().At(i)
+		R.states[i] = q.(State)
+		R.index[q.(State)] = i
+	}
+
+	// successors[a][i] are the indices of step(states[i], a)
+	successors := make([][][]int, A.Alphabet().Size())
+	for k := 0; k < A.Alphabet().Size(); k += 1 {
+		a, _ := A.Alphabet().At(k)
+		successors[k] = make([][]int, n)
+		for i, q := range R.states {
+			Q := step(q, a.(Letter))
+			for l := 0; l < Q.Size(); l += 1 {
+				r, _ := Q.At(l)
+				successors[k][i] = append(successors[k][i], R.index[r.(State)])
+			}
+		}
+	}
+
+	for i, p := range R.states {
+		R.simulates[i] = make([]bool, n)
+		for j, q := range R.states {
+			R.simulates[i][j] = S.Probe(p) == false || S.Probe(q) == true
+		}
+	}
+
+	for changed := true; changed == true; {
+		changed = false
+
+		for i := 0; i < n; i += 1 {
+			for j := 0; j < n; j += 1 {
+				if i == j || R.simulates[i][j] == false {
+					continue
+				}
+
+			letters:
+				for k := range successors {
+					for _, i_ := range successors[k][i] {
+						answered := false
+						for _, j_ := range successors[k][j] {
+							if R.simulates[i_][j_] == true {
+								answered = true
+								break
+							}
+						}
+						if answered == false {
+							R.simulates[i][j] = false
+							changed = true
+							break letters
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return R
+}
+
+func simulationToMap(R simulationRelation) map[State]StateSet {
+	m := make(map[State]StateSet)
+	for i, p := range R.states {
+		m[p] = set.NewSet()
+		for j, q := range R.states {
+			if R.simulates[i][j] == true {
+				m[p].Add(q)
+			}
+		}
+	}
+	return m
+}
+
+func predecessorFunction(A Nfa) func(State, Letter) StateSet {
+	predecessors := make(map[State]map[Letter]StateSet)
+
+	for i := 0; i < A.States().Size(); i += 1 {
+		for j := 0; j < A.Alphabet().Size(); j += 1 {
+			q, _ := A.States().At(i)
+			a, _ := A.Alphabet().At(j)
+
+			R := A.Transition(q.(State), a.(Letter))
+			for k := 0; k < R.Size(); k += 1 {
+				r, _ := R.At(k)
+				if _, ok := predecessors[r.(State)]; ok == false {
+					predecessors[r.(State)] = make(map[Letter]StateSet)
+				}
+				if _, ok := predecessors[r.(State)][a.(Letter)]; ok == false {
+					predecessors[r.(State)][a.(Letter)] = set.NewSet()
+				}
+				predecessors[r.(State)][a.(Letter)].Add(q)
+			}
+		}
+	}
+
+	return func(q State, a Letter) StateSet {
+		if P, ok := predecessors[q][a]; ok == true {
+			return P
+		} //else {
+		return set.NewSet()
+		//}
+	}
+}
+
+// every class of equivalent states is replaced by its first state
+func quotientBySimulation(A Nfa, R simulationRelation) Nfa {
+	representative := make(map[State]State)
+	for i, p := range R.states {
+		for j := 0; j <= i; j += 1 {
+			if R.equivalent(i, j) == true {
+				representative[p] = R.states[j]
+				break
+			}
+		}
+	}
+
+	B := NewNfa()
+	B.SetAlphabet(A.Alphabet().Copy().(Alphabet))
+
+	for _, p := range R.states {
+		r := representative[p]
+		B.States().Add(r)
+		if A.InitialStates().Probe(p) == true {
+			B.InitialStates().Add(r)
+		}
+		if A.FinalStates().Probe(p) == true {
+			B.FinalStates().Add(r)
+		}
+
+		for k := 0; k < A.Alphabet().Size(); k += 1 {
+			a, _ := A.Alphabet().At(k)
+
+			Q := A.Transition(p, a.(Letter))
+			Q_ := B.Transition(r, a.(Letter)).Copy().(StateSet)
+			for l := 0; l < Q.Size(); l += 1 {
+				q, _ := Q.At(l)
+				Q_.Add(representative[q.(State)])
+			}
+			B.SetTransition(r, a.(Letter), Q_)
+		}
+	}
+
+	return B
+}
+
+// removes the transitions into, and initial states, which are strictly simulated by a sibling
+func pruneBySimulation(A Nfa, R simulationRelation) Nfa {
+	strictlySimulated := func(S StateSet, q State) bool {
+		i := R.index[q]
+		for l := 0; l < S.Size(); l += 1 {
+			r, _ := S.At(l)
+			j := R.index[r.(State)]
+			if R.simulates[i][j] == true && R.simulates[j][i] == false {
+				return true
+			}
+		}
+		return false
+	}
+
+	prune := func(S StateSet) StateSet {
+		S_ := set.NewSet()
+		for l := 0; l < S.Size(); l += 1 {
+			q, _ := S.At(l)
+			if strictlySimulated(S, q.(State)) == false {
+				S_.Add(q)
+			}
+		}
+		return S_
+	}
+
+	B := A.Copy()
+	B.SetInitialStates(prune(A.InitialStates()))
+
+	for i := 0; i < A.States().Size(); i += 1 {
+		for k := 0; k < A.Alphabet().Size(); k += 1 {
+			p, _ := A.States().At(i)
+			a, _ := A.Alphabet().At(k)
+			B.SetTransition(p.(State), a.(Letter), prune(A.Transition(p.(State), a.(Letter))))
+		}
+	}
+
+	return B
+}
+
+// letter and epsilon transitions
+func transitionCount(A Nfa) int {
+	count := 0
+	for i := 0; i < A.States().Size(); i += 1 {
+		q, _ := A.States().At(i)
+		for k := 0; k < A.Alphabet().Size(); k += 1 {
+			a, _ := A.Alphabet().At(k)
+			count += A.Transition(q.(State), a.(Letter)).Size()
+		}
+		count += A.EpsilonTransition(q.(State)).Size()
+	}
+	return count
+}
@@ -0,0 +1,126 @@
+package nfa
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+//	 +-- a --> 1 -- b --> |3|
+//	/
+//
+// --> 0 --- a --> 2 -- b --> |4|
+//
+//	\
+//	 +- c --> |4|
+var simulationExample = []byte(`{"States":["0","1","2","3","4"],"Alphabet":["a","b","c"],"InitialStates":["0"],"Transitions":{"0":{"a":["1","2"]},"1":{"b":["3"]},"2":{"b":["4"],"c":["4"]}},"FinalStates":["3","4"]}`)
+
+func TestForwardSimulation(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal(simulationExample, &A)
+
+	sim := ForwardSimulation(A)
+
+	type test struct {
+		p, q              State
+		shouldBeSimulated bool
+	}
+
+	tests := []test{
+		test{"1", "2", true},
+		test{"2", "1", false},
+		test{"3", "4", true},
+		test{"4", "3", true},
+		test{"0", "0", true},
+		test{"3", "1", false},
+		test{"1", "3", false},
+		test{"0", "2", false},
+	}
+
+	for k, x := range tests {
+		if sim[x.p].Probe(x.q) != x.shouldBeSimulated {
+			t.Error("case ", k, x.p, sim[x.p])
+		}
+	}
+}
+
+func TestBackwardSimulation(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal(simulationExample, &A)
+
+	sim := BackwardSimulation(A)
+
+	type test struct {
+		p, q              State
+		shouldBeSimulated bool
+	}
+
+	tests := []test{
+		test{"1", "2", true},
+		test{"2", "1", true},
+		test{"3", "4", true},
+		test{"4", "3", false},
+		test{"0", "1", false},
+		test{"1", "0", false},
+	}
+
+	for k, x := range tests {
+		if sim[x.p].Probe(x.q) != x.shouldBeSimulated {
+			t.Error("case ", k, x.p, sim[x.p])
+		}
+	}
+}
+
+func TestReduceBySimulation(t *testing.T) {
+	A := NewNfa()
+	json.Unmarshal(simulationExample, &A)
+
+	// 3 and 4 are merged, the transition to 1 is pruned, then 1 is useless
+	B, states, transitions := ReduceBySimulation(A)
+
+	s := []byte(`{"States":["0","2","3"],"Alphabet":["a","b","c"],"InitialStates":["0"],"Transitions":{"0":{"a":["2"]},"2":{"b":["3"],"c":["3"]}},"FinalStates":["3"]}`)
+	compareNfaToMarshaledNfa(B, s, true, t, "")
+
+	if states != 2 || transitions != 2 {
+		t.Error(states, transitions)
+	}
+
+	type test struct {
+		A Nfa
+	}
+
+	tests := []test{
+		// ((a.b)+(a.b))*
+		test{KleeneStar(Union(Concat(OneLetter("a"), OneLetter("b")), Concat(OneLetter("a"), OneLetter("b"))))},
+		// ((a)*.(a)*).b
+		test{Concat(Concat(KleeneStar(OneLetter("a")), KleeneStar(OneLetter("a"))), OneLetter("b"))},
+		// ((a+b))*.a.(a+b)
+		test{Concat(Concat(KleeneStar(Union(OneLetter("a"), OneLetter("b"))), OneLetter("a")), Union(OneLetter("a"), OneLetter("b")))},
+		test{Intersect(OneLetter("a"), OneLetter("b"))},
+	}
+
+	for k, x := range tests {
+		B, states, transitions := ReduceBySimulation(x.A)
+
+		if equal, counterexample := Equivalent(x.A, B); equal != true {
+			t.Error("case ", k, counterexample)
+		}
+
+		A := withoutEpsilons(x.A)
+		if states != A.States().Size()-B.States().Size() || transitions != transitionCount(A)-transitionCount(B) || states < 0 || transitions < 0 {
+			t.Error("case ", k, states, transitions)
+		}
+	}
+
+	// the two copies of a.b are merged
+	C, _, _ := ReduceBySimulation(tests[0].A)
+	if C.States().Size() != 2 {
+		t.Error(C)
+	}
+
+	// epsilon elimination adds transitions, they are not counted as added by the reduction
+	D := NewNfa()
+	json.Unmarshal([]byte(`{"States":["0","1","2","3","4"],"Alphabet":["a","b","c","d"],"InitialStates":["0"],"Transitions":{"1":{"a":["1"]},"2":{"b":["2"]},"3":{"c":["3"]},"4":{"d":["4"]}},"EpsilonTransitions":{"0":["1"],"1":["2"],"2":["3"],"3":["4"]},"FinalStates":["4"]}`), &D)
+	if E, states, transitions := ReduceBySimulation(D); states != 1 || transitions != transitionCount(D.EliminateEpsilons())-transitionCount(E) || transitions <= 0 {
+		t.Error(states, transitions, E)
+	}
+}
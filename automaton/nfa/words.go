@@ -0,0 +1,140 @@
+package nfa
+
+// enumerates the words accepted by an Nfa in length-lexicographic order.
+// letters are ordered as in Alphabet().At(i).
+//
+// the words of one length are found by a depth first search on the trimmed subset automaton,
+// which only follows letters that lead to a final state in exactly the remaining number of steps.
+// hence only the current word is kept, and the iteration ends on finite languages.
+type WordIterator struct {
+	automaton Nfa // deterministic and trimmed
+	maxLength int
+	length    int
+	exact     []map[State]bool // exact[r]: the states with an accepted word of length r
+	stack     []frame
+	word      []Letter
+}
+
+// a state on the path of the search, and the index of the next letter to try from it
+type frame struct {
+	state State
+	next  int
+}
+
+// words longer than maxLength are not enumerated. a negative maxLength means no limit.
+func NewWordIterator(A Nfa, maxLength int) *WordIterator {
+	B := determinize(A, A.Alphabet()).Trim()
+
+	exact := make(map[State]bool)
+	for i := 0; i < B.FinalStates().Size(); i += 1 {
+		q, _ := B.FinalStates().At(i)
+		exact[q.(State)] = true
+	}
+
+	return &WordIterator{B, maxLength, -1, []map[State]bool{exact}, make([]frame, 0), make([]Letter, 0)}
+}
+
+// returns false if there are no more words
+func (it *WordIterator) Next() ([]Letter, bool) {
+	for {
+		if len(it.stack) == 0 && it.nextLength() == false {
+			return nil, false
+		}
+
+		for len(it.stack) > 0 {
+			top := &it.stack[len(it.stack)-1]
+			depth := len(it.stack) - 1
+
+			if depth == it.length {
+				w := append(make([]Letter, 0, it.length), it.word...)
+				it.pop()
+				return w, true
+			}
+
+			if top.next == it.automaton.Alphabet().Size() {
+				it.pop()
+				continue
+			}
+
+			a, _ := it.automaton.Alphabet().At(top.next)
+			top.next += 1
+
+			if r, ok := it.successor(top.state, a.(Letter)); ok == true && it.exact[it.length-depth-1][r] == true {
+				it.stack = append(it.stack, frame{r, 0})
+				it.word = append(it.word, a.(Letter))
+			}
+		}
+	}
+}
+
+// starts the search for the words of the next length.
+// returns false if there are none of this or any greater length.
+func (it *WordIterator) nextLength() bool {
+	for {
+		// no word of some length means no longer words either
+		if it.maxLength >= 0 && it.length == it.maxLength || len(it.exact[len(it.exact)-1]) == 0 {
+			return false
+		}
+		it.length += 1
+
+		if len(it.exact) <= it.length {
+			it.exact = append(it.exact, it.predecessors(it.exact[len(it.exact)-1]))
+		}
+
+		for i := 0; i < it.automaton.InitialStates().Size(); i += 1 {
+			q, _ := it.automaton.InitialStates().At(i)
+			if it.exact[it.length][q.(State)] == true {
+				it.stack = append(it.stack, frame{q.(State), 0})
+				return true
+			}
+		}
+	}
+}
+
+// the states with a transition into S
+func (it *WordIterator) predecessors(S map[State]bool) map[State]bool {
+	ret := make(map[State]bool)
+	for i := 0; i < it.automaton.States().Size(); i += 1 {
+		q, _ := it.automaton.States().At(i)
+		for j := 0; j < it.automaton.Alphabet().Size(); j += 1 {
+			a, _ := it.automaton.Alphabet().At(j)
+			if r, ok := it.successor(q.(State), a.(Letter)); ok == true && S[r] == true {
+				ret[q.(State)] = true
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// the automaton is deterministic, but not complete after trimming
+func (it *WordIterator) successor(q State, a Letter) (State, bool) {
+	R := it.automaton.Transition(q, a)
+	if R.Size() == 0 {
+		return "", false
+	}
+	r, _ := R.At(0)
+	return r.(State), true
+}
+
+func (it *WordIterator) pop() {
+	it.stack = it.stack[:len(it.stack)-1]
+	if len(it.word) > 0 {
+		it.word = it.word[:len(it.word)-1]
+	}
+}
+
+// the first n words in length-lexicographic order, or less if the language is smaller.
+// there are none for n <= 0.
+func Words(A Nfa, n int) [][]Letter {
+	words := make([][]Letter, 0)
+	it := NewWordIterator(A, -1)
+	for len(words) < n {
+		w, ok := it.Next()
+		if ok == false {
+			break
+		}
+		words = append(words, w)
+	}
+	return words
+}
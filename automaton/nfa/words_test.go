@@ -0,0 +1,60 @@
+package nfa
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestWordIterator(t *testing.T) {
+	type test struct {
+		A         Nfa
+		maxLength int
+		words     string
+	}
+
+	tests := []test{
+		test{Concat(Union(OneLetter("a"), OneLetter("b")), KleeneStar(OneLetter("c"))), 3, "[[a] [b] [a c] [b c] [a c c] [b c c]]"},
+		test{Concat(OneLetter("a"), Union(OneLetter("c"), OneLetter("b"))), -1, "[[a c] [a b]]"},
+		test{KleeneStar(OneLetter("a")), 2, "[[] [a] [a a]]"},
+		test{KleeneStar(Union(OneLetter("b"), OneLetter("a"))), 2, "[[] [b] [a] [b b] [b a] [a b] [a a]]"},
+		test{Intersect(OneLetter("a"), OneLetter("b")), -1, "[]"},
+		test{NewNfa(), -1, "[]"},
+		test{Union(Concat(OneLetter("a"), KleeneStar(OneLetter("b"))), OneLetter("c")), 0, "[]"},
+		test{KleeneStar(Concat(OneLetter("a"), OneLetter("a"))), 4, "[[] [a a] [a a a a]]"},
+		test{Concat(KleeneStar(OneLetter("a")), Concat(OneLetter("b"), OneLetter("b"))), 3, "[[b b] [a b b]]"},
+	}
+
+	for k, x := range tests {
+		words := make([][]Letter, 0)
+		it := NewWordIterator(x.A, x.maxLength)
+		for w, ok := it.Next(); ok == true; w, ok = it.Next() {
+			words = append(words, w)
+		}
+
+		if fmt.Sprint(words) != x.words {
+			t.Error("case ", k, " should:", x.words, " is:", words)
+		}
+
+		// the iterator stays exhausted
+		if w, ok := it.Next(); ok != false || w != nil {
+			t.Error("case ", k, w)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	// (a.(b)*)
+	A := Concat(OneLetter("a"), KleeneStar(OneLetter("b")))
+
+	if words := Words(A, 3); fmt.Sprint(words) != "[[a] [a b] [a b b]]" {
+		t.Error(words)
+	}
+
+	if words := Words(Union(OneLetter("a"), OneLetter("b")), 3); fmt.Sprint(words) != "[[a] [b]]" {
+		t.Error(words)
+	}
+
+	if words := Words(A, -1); len(words) != 0 {
+		t.Error(words)
+	}
+}
@@ -0,0 +1,594 @@
+package render
+
+import (
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"io"
+	"strings"
+	"unicode"
+)
+
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprint("line ", e.Line, ", column ", e.Column, ": ", e.Message)
+}
+
+// reads the subset of DOT written by Dot and most automaton tools:
+// a digraph of node, edge and attribute statements, without subgraphs and ports.
+//
+// nodes with style=invis or shape point, none or plaintext are start nodes.
+// they are no states, instead they mark the targets of their edges as initial.
+// nodes with shape=doublecircle are final.
+// edge labels are comma separated lists of letters, ε stands for an epsilon transition.
+// a backslash escapes the next character, e.g. a comma, and \ε is the letter ε.
+// the alphabet consists of the letters on the edges and in the graph attribute alphabet.
+func ParseDot(r io.Reader) (nfa.Nfa, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{lexer: lexer{input: []rune(string(b)), line: 1, column: 1}, nodes: make(map[string]*dotNode), graphAttributes: make(map[string]string), nodeDefaults: make(map[string]string), edgeDefaults: make(map[string]string)}
+	if err := p.parseGraph(); err != nil {
+		return nil, err
+	}
+
+	return p.nfa()
+}
+
+/*****************************************************************************/
+
+const (
+	idToken = iota
+	punctuationToken
+	endToken
+)
+
+type token struct {
+	kind         int
+	text         string
+	line, column int
+}
+
+type lexer struct {
+	input        []rune
+	position     int
+	line, column int
+}
+
+func (l *lexer) peek(k int) rune {
+	if l.position+k < len(l.input) {
+		return l.input[l.position+k]
+	}
+	return 0
+}
+
+func (l *lexer) advance() rune {
+	c := l.input[l.position]
+	l.position += 1
+	if c == '\n' {
+		l.line += 1
+		l.column = 1
+	} else {
+		l.column += 1
+	}
+	return c
+}
+
+func (l *lexer) errorf(line, column int, format string, args ...interface{}) error {
+	return SyntaxError{line, column, fmt.Sprintf(format, args...)}
+}
+
+func isIdRune(c rune) bool {
+	return c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c) || c > unicode.MaxASCII
+}
+
+func (l *lexer) next() (token, error) {
+
+	// white space and comments
+	for l.position < len(l.input) {
+		c := l.peek(0)
+		if unicode.IsSpace(c) {
+			l.advance()
+		} else if c == '#' && l.column == 1 {
+			for l.position < len(l.input) && l.peek(0) != '\n' {
+				l.advance()
+			}
+		} else if c == '/' && l.peek(1) == '/' {
+			for l.position < len(l.input) && l.peek(0) != '\n' {
+				l.advance()
+			}
+		} else if c == '/' && l.peek(1) == '*' {
+			line, column := l.line, l.column
+			l.advance()
+			l.advance()
+			for l.position < len(l.input) && (l.peek(0) != '*' || l.peek(1) != '/') {
+				l.advance()
+			}
+			if l.position == len(l.input) {
+				return token{}, l.errorf(line, column, "unterminated comment")
+			}
+			l.advance()
+			l.advance()
+		} else {
+			break
+		}
+	}
+
+	line, column := l.line, l.column
+
+	if l.position == len(l.input) {
+		return token{endToken, "", line, column}, nil
+	}
+
+	c := l.peek(0)
+
+	switch {
+	case c == '"':
+		l.advance()
+		text := make([]rune, 0)
+		for {
+			if l.position == len(l.input) {
+				return token{}, l.errorf(line, column, "unterminated string")
+			}
+			c := l.advance()
+			if c == '"' {
+				break
+			} else if c == '\\' && (l.peek(0) == '"' || l.peek(0) == '\\') {
+				text = append(text, l.advance())
+			} else if c == '\\' && l.peek(0) == '\n' {
+				l.advance()
+			} else {
+				text = append(text, c)
+			}
+		}
+		return token{idToken, string(text), line, column}, nil
+
+	case c == '-' && (l.peek(1) == '>' || l.peek(1) == '-'):
+		l.advance()
+		l.advance()
+		return token{punctuationToken, "-" + string(l.input[l.position-1]), line, column}, nil
+
+	case c == '-' || isIdRune(c):
+		text := []rune{l.advance()}
+		for l.position < len(l.input) && isIdRune(l.peek(0)) {
+			text = append(text, l.advance())
+		}
+		return token{idToken, string(text), line, column}, nil
+
+	case strings.ContainsRune("{}[]=;,:", c):
+		l.advance()
+		return token{punctuationToken, string(c), line, column}, nil
+
+	case c == '<':
+		return token{}, l.errorf(line, column, "html strings are not supported")
+	}
+
+	return token{}, l.errorf(line, column, "unexpected character %q", c)
+}
+
+/*****************************************************************************/
+
+type dotNode struct {
+	name       string
+	attributes map[string]string
+}
+
+type dotEdge struct {
+	from, to     string
+	attributes   map[string]string
+	line, column int
+}
+
+type parser struct {
+	lexer     lexer
+	lookahead *token
+
+	nodes           map[string]*dotNode
+	nodeOrder       []string
+	edges           []dotEdge
+	graphAttributes map[string]string
+	alphabetAt      token // the statement which set the alphabet
+	nodeDefaults    map[string]string
+	edgeDefaults    map[string]string
+}
+
+func (p *parser) peek() (token, error) {
+	if p.lookahead == nil {
+		t, err := p.lexer.next()
+		if err != nil {
+			return token{}, err
+		}
+		p.lookahead = &t
+	}
+	return *p.lookahead, nil
+}
+
+func (p *parser) next() (token, error) {
+	t, err := p.peek()
+	p.lookahead = nil
+	return t, err
+}
+
+func (p *parser) expect(text string) (token, error) {
+	t, err := p.next()
+	if err != nil {
+		return t, err
+	}
+	if t.kind != punctuationToken || t.text != text {
+		return t, p.unexpected(t, "'"+text+"'")
+	}
+	return t, nil
+}
+
+func (p *parser) expectId() (token, error) {
+	t, err := p.next()
+	if err != nil {
+		return t, err
+	}
+	if t.kind != idToken {
+		return t, p.unexpected(t, "an identifier")
+	}
+	return t, nil
+}
+
+func (p *parser) unexpected(t token, expected string) error {
+	found := "'" + t.text + "'"
+	if t.kind == endToken {
+		found = "end of input"
+	} else if t.kind == idToken {
+		found = fmt.Sprintf("%q", t.text)
+	}
+	return p.lexer.errorf(t.line, t.column, "expected %s, found %s", expected, found)
+}
+
+// is the next token the given punctuation
+func (p *parser) at(text string) (bool, error) {
+	t, err := p.peek()
+	if err != nil {
+		return false, err
+	}
+	return t.kind == punctuationToken && t.text == text, nil
+}
+
+func isKeyword(t token, keyword string) bool {
+	return t.kind == idToken && strings.ToLower(t.text) == keyword
+}
+
+// [strict] digraph [ID] { stmt_list }
+func (p *parser) parseGraph() error {
+	t, err := p.expectId()
+	if err != nil {
+		return err
+	}
+	if isKeyword(t, "strict") == true {
+		if t, err = p.expectId(); err != nil {
+			return err
+		}
+	}
+	if isKeyword(t, "graph") == true {
+		return p.lexer.errorf(t.line, t.column, "undirected graphs are not supported")
+	} else if isKeyword(t, "digraph") == false {
+		return p.unexpected(t, "'digraph'")
+	}
+
+	if t, err = p.peek(); err != nil {
+		return err
+	} else if t.kind == idToken {
+		p.next()
+	}
+
+	if _, err := p.expect("{"); err != nil {
+		return err
+	}
+
+	for {
+		if closing, err := p.at("}"); err != nil {
+			return err
+		} else if closing == true {
+			break
+		}
+		if err := p.parseStatement(); err != nil {
+			return err
+		}
+	}
+	p.next()
+
+	t, err = p.next()
+	if err != nil {
+		return err
+	} else if t.kind != endToken {
+		return p.unexpected(t, "end of input")
+	}
+
+	return nil
+}
+
+// attr_stmt | ID = ID | node_stmt | edge_stmt, optionally followed by ;
+func (p *parser) parseStatement() error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+
+	if t.kind == punctuationToken && t.text == "{" || isKeyword(t, "subgraph") == true {
+		return p.lexer.errorf(t.line, t.column, "subgraphs are not supported")
+	} else if t.kind != idToken {
+		return p.unexpected(t, "a statement")
+	}
+
+	if isKeyword(t, "graph") == true || isKeyword(t, "node") == true || isKeyword(t, "edge") == true {
+		attributes, err := p.parseAttributeLists()
+		if err != nil {
+			return err
+		}
+		defaults := p.graphAttributes
+		if isKeyword(t, "node") == true {
+			defaults = p.nodeDefaults
+		} else if isKeyword(t, "edge") == true {
+			defaults = p.edgeDefaults
+		}
+		for k, v := range attributes {
+			defaults[k] = v
+		}
+		if _, ok := attributes["alphabet"]; ok == true && isKeyword(t, "graph") == true {
+			p.alphabetAt = t
+		}
+
+	} else if assignment, err := p.at("="); err != nil {
+		return err
+	} else if assignment == true {
+		// graph attribute
+		p.next()
+		v, err := p.expectId()
+		if err != nil {
+			return err
+		}
+		p.graphAttributes[strings.ToLower(t.text)] = v.text
+		if strings.ToLower(t.text) == "alphabet" {
+			p.alphabetAt = t
+		}
+
+	} else {
+		// node or edge statement
+		ids := []token{t}
+		for {
+			if port, err := p.at(":"); err != nil {
+				return err
+			} else if port == true {
+				u, _ := p.peek()
+				return p.lexer.errorf(u.line, u.column, "ports are not supported")
+			}
+
+			u, err := p.peek()
+			if err != nil {
+				return err
+			}
+			if u.kind == punctuationToken && u.text == "--" {
+				return p.lexer.errorf(u.line, u.column, "undirected edges are not supported")
+			} else if u.kind != punctuationToken || u.text != "->" {
+				break
+			}
+			p.next()
+
+			v, err := p.expectId()
+			if err != nil {
+				return err
+			}
+			ids = append(ids, v)
+		}
+
+		attributes, err := p.parseAttributeLists()
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			p.node(id.text)
+		}
+
+		if len(ids) == 1 {
+			for k, v := range attributes {
+				p.nodes[t.text].attributes[k] = v
+			}
+		} else {
+			for i := 0; i+1 < len(ids); i += 1 {
+				e := dotEdge{ids[i].text, ids[i+1].text, make(map[string]string), ids[i].line, ids[i].column}
+				for k, v := range p.edgeDefaults {
+					e.attributes[k] = v
+				}
+				for k, v := range attributes {
+					e.attributes[k] = v
+				}
+				p.edges = append(p.edges, e)
+			}
+		}
+	}
+
+	if semicolon, err := p.at(";"); err != nil {
+		return err
+	} else if semicolon == true {
+		p.next()
+	}
+
+	return nil
+}
+
+// zero or more [ ID = ID [,;] ... ]
+func (p *parser) parseAttributeLists() (map[string]string, error) {
+	attributes := make(map[string]string)
+
+	for {
+		if open, err := p.at("["); err != nil {
+			return nil, err
+		} else if open == false {
+			return attributes, nil
+		}
+		p.next()
+
+		for {
+			if closing, err := p.at("]"); err != nil {
+				return nil, err
+			} else if closing == true {
+				p.next()
+				break
+			}
+
+			k, err := p.expectId()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect("="); err != nil {
+				return nil, err
+			}
+			v, err := p.expectId()
+			if err != nil {
+				return nil, err
+			}
+			attributes[strings.ToLower(k.text)] = v.text
+
+			if separator, err := p.peek(); err != nil {
+				return nil, err
+			} else if separator.kind == punctuationToken && (separator.text == "," || separator.text == ";") {
+				p.next()
+			}
+		}
+	}
+}
+
+// creates a node with the current default attributes, if it does not exist yet
+func (p *parser) node(name string) {
+	if _, ok := p.nodes[name]; ok == true {
+		return
+	}
+	n := &dotNode{name, make(map[string]string)}
+	for k, v := range p.nodeDefaults {
+		n.attributes[k] = v
+	}
+	p.nodes[name] = n
+	p.nodeOrder = append(p.nodeOrder, name)
+}
+
+func isStartNode(n *dotNode) bool {
+	shape := strings.ToLower(n.attributes["shape"])
+	return strings.Contains(strings.ToLower(n.attributes["style"]), "invis") == true || shape == "point" || shape == "none" || shape == "plaintext"
+}
+
+// splits at unescaped commas and trims unescaped white space.
+// epsilon[k] tells whether letters[k] is an unescaped ε.
+// returns false if a letter is empty.
+func splitLabel(label string) (letters []string, epsilon []bool, ok bool) {
+	letter := make([]rune, 0)
+	escaped := make([]bool, 0)
+
+	finish := func() bool {
+		begin, end := 0, len(letter)
+		for begin < end && escaped[begin] == false && unicode.IsSpace(letter[begin]) == true {
+			begin += 1
+		}
+		for end > begin && escaped[end-1] == false && unicode.IsSpace(letter[end-1]) == true {
+			end -= 1
+		}
+		if begin == end {
+			return false
+		}
+
+		a := string(letter[begin:end])
+		letters = append(letters, a)
+		epsilon = append(epsilon, a == Epsilon && escaped[begin] == false)
+
+		letter, escaped = letter[:0], escaped[:0]
+		return true
+	}
+
+	runes := []rune(label)
+	for i := 0; i < len(runes); i += 1 {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			i += 1
+			letter = append(letter, runes[i])
+			escaped = append(escaped, true)
+		} else if runes[i] == ',' {
+			if finish() == false {
+				return nil, nil, false
+			}
+		} else {
+			letter = append(letter, runes[i])
+			escaped = append(escaped, false)
+		}
+	}
+	if finish() == false {
+		return nil, nil, false
+	}
+
+	return letters, epsilon, true
+}
+
+func (p *parser) nfa() (nfa.Nfa, error) {
+	A := nfa.NewNfa()
+
+	if alphabet, ok := p.graphAttributes["alphabet"]; ok == true && strings.TrimSpace(alphabet) != "" {
+		letters, epsilon, ok := splitLabel(alphabet)
+		if ok == false {
+			return nil, SyntaxError{p.alphabetAt.line, p.alphabetAt.column, "the alphabet has an empty letter"}
+		}
+		for k, a := range letters {
+			if epsilon[k] == true {
+				return nil, SyntaxError{p.alphabetAt.line, p.alphabetAt.column, "the alphabet contains " + Epsilon}
+			}
+			A.Alphabet().Add(nfa.Letter(a))
+		}
+	}
+
+	for _, name := range p.nodeOrder {
+		n := p.nodes[name]
+		if isStartNode(n) == true {
+			continue
+		}
+		A.States().Add(nfa.State(name))
+		if strings.ToLower(n.attributes["shape"]) == "doublecircle" {
+			A.FinalStates().Add(nfa.State(name))
+		}
+	}
+
+	for _, e := range p.edges {
+		from, to := p.nodes[e.from], p.nodes[e.to]
+
+		if isStartNode(to) == true {
+			return nil, SyntaxError{e.line, e.column, fmt.Sprintf("edge into the start node %q", e.to)}
+		} else if isStartNode(from) == true {
+			A.InitialStates().Add(nfa.State(e.to))
+			continue
+		}
+
+		label, ok := e.attributes["label"]
+		if ok == false {
+			return nil, SyntaxError{e.line, e.column, fmt.Sprintf("edge %q -> %q has no label", e.from, e.to)}
+		}
+
+		letters, epsilon, ok := splitLabel(label)
+		if ok == false {
+			return nil, SyntaxError{e.line, e.column, fmt.Sprintf("edge %q -> %q has an empty letter", e.from, e.to)}
+		}
+
+		q, r := nfa.State(e.from), nfa.State(e.to)
+		for k, a := range letters {
+			if epsilon[k] == true {
+				R := A.EpsilonTransition(q).Copy().(nfa.StateSet)
+				R.Add(r)
+				A.SetEpsilonTransition(q, R)
+			} else {
+				A.Alphabet().Add(nfa.Letter(a))
+				R := A.Transition(q, nfa.Letter(a)).Copy().(nfa.StateSet)
+				R.Add(r)
+				A.SetTransition(q, nfa.Letter(a), R)
+			}
+		}
+	}
+
+	return A, nil
+}
@@ -0,0 +1,111 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"strings"
+	"testing"
+)
+
+func TestParseDotRoundTrip(t *testing.T) {
+	A := nfa.NewNfa()
+	json.Unmarshal(example, &A)
+
+	B := nfa.NewNfa()
+	B.States().Add(nfa.State(`"q" \ 1`))
+	B.States().Add(nfa.State("(0,1)"))
+	B.Alphabet().Add(nfa.Letter("a"))
+	B.InitialStates().Add(nfa.State(`"q" \ 1`))
+	B.InitialStates().Add(nfa.State("(0,1)"))
+	B.FinalStates().Add(nfa.State("(0,1)"))
+	B.SetTransition(`"q" \ 1`, "a", B.InitialStates().Copy().(nfa.StateSet))
+
+	// letters which need escaping, and one without transitions
+	C := B.Copy()
+	for _, a := range []nfa.Letter{"a,b", nfa.Letter(Epsilon), ` \ `, "unused"} {
+		C.Alphabet().Add(a)
+	}
+	C.SetTransition("(0,1)", "a,b", C.States())
+	C.SetTransition("(0,1)", nfa.Letter(Epsilon), C.States())
+	C.SetTransition(`"q" \ 1`, ` \ `, C.FinalStates())
+	C.SetEpsilonTransition("(0,1)", C.InitialStates())
+
+	for k, X := range []nfa.Nfa{A, B, C} {
+		var b bytes.Buffer
+		Dot(&b, X, Options{Run: []nfa.State{"0", "1"}})
+
+		Y, err := ParseDot(&b)
+		if err != nil {
+			t.Error("case", k, err)
+		} else if X.IsEqual(Y) == false {
+			t.Error("case", k, Y)
+		} else if equivalent, w := nfa.Equivalent(X, Y); equivalent == false {
+			t.Error("case", k, w)
+		}
+	}
+}
+
+func TestParseDot(t *testing.T) {
+	dot := `
+# a hand-drawn automaton
+strict digraph "ends with ab" {
+	graph [rankdir=LR]
+	node [shape = circle]; /* states */
+	s0; s1
+	s2 [shape=doublecircle]
+	node [shape=none, label=""]
+	init
+	edge [label=b]
+	init -> s0 -> s0 [label="a, b"] // a chain
+	s0 -> s1 [label=a]
+	s1 -> s2
+	s2 -> s0 [label="a,ε"]
+	s2 -> s2 [label="\\ε, c\\,d ,\\ "]
+}
+`
+	A, err := ParseDot(strings.NewReader(dot))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := nfa.NewNfa()
+	json.Unmarshal([]byte(`{"States":["s0","s1","s2"],"Alphabet":["a","b","ε","c,d"," "],"InitialStates":["s0"],"Transitions":{"s0":{"a":["s0","s1"],"b":["s0"]},"s1":{"b":["s2"]},"s2":{"a":["s0"],"ε":["s2"],"c,d":["s2"]," ":["s2"]}},"EpsilonTransitions":{"s2":["s0"]},"FinalStates":["s2"]}`), &expected)
+
+	if A.IsEqual(expected) == false {
+		t.Error(A)
+	}
+}
+
+func TestParseDotErrors(t *testing.T) {
+	type test struct {
+		dot          string
+		line, column int
+	}
+
+	tests := []test{
+		test{`graph { a -- b }`, 1, 1},
+		test{`digraph {`, 1, 10},
+		test{"digraph {\n  a -> [label=x]\n}", 2, 8},
+		test{"digraph {\n  a -> b [label=\"x]\n}", 2, 17},
+		test{"digraph {\n  a -> b\n}", 2, 3},
+		test{"digraph {\n  a -> b [label=\"x,,y\"]\n}", 2, 3},
+		test{"digraph {\n  a; b [style=invis]\n  a -> b [label=x]\n}", 3, 3},
+		test{"digraph {\n  subgraph { a }\n}", 2, 3},
+		test{"digraph {\n  a:n -> b\n}", 2, 4},
+		test{"digraph {\n  /* a\n}", 2, 3},
+		test{"digraph { a } b", 1, 15},
+		test{"digraph { a @ }", 1, 13},
+		test{"digraph {\n  alphabet=\"a,,b\"\n}", 2, 3},
+		test{"digraph {\n  graph [alphabet=\"a,ε\"]\n}", 2, 3},
+	}
+
+	for k, x := range tests {
+		_, err := ParseDot(strings.NewReader(x.dot))
+		if e, ok := err.(SyntaxError); ok == false {
+			t.Error("case", k, err)
+		} else if e.Line != x.line || e.Column != x.column {
+			t.Error("case", k, e)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"io"
+	"strings"
+	"unicode"
+)
+
+type Options struct {
+	// the name of the graph, "A" if empty
+	Name string
+
+	// states of the run and the edges between consecutive states are highlighted.
+	// the output of nfa.Nfa.AcceptingRun can be used directly.
+	Run []nfa.State
+}
+
+// written as label of epsilon transitions
+const Epsilon = "ε"
+
+// initial states get an arrow from an invisible start node, final states are double circles.
+// parallel edges are merged into one edge labeled "a,b".
+// in labels, commas, backslashes and white space inside letters are escaped with a backslash,
+// as is a letter named ε. the alphabet is written as graph attribute, so that ParseDot
+// also restores letters without transitions. the empty letter cannot be written.
+func Dot(w io.Writer, A nfa.Nfa, opts Options) error {
+	letters := make([]string, A.Alphabet().Size())
+	for k := 0; k < A.Alphabet().Size(); k += 1 {
+		a, _ := A.Alphabet().At(k)
+		if a.(nfa.Letter) == "" {
+			return errors.New("the empty letter cannot be written in DOT")
+		}
+		letters[k] = escapeLetter(string(a.(nfa.Letter)))
+	}
+
+	g := newGraph(A, opts)
+
+	quote := func(s string) string {
+		return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+	}
+
+	ret := ""
+	ret += fmt.Sprintln("digraph", quote(g.name), "{")
+	ret += fmt.Sprintln("\trankdir=LR;")
+	ret += fmt.Sprint("\talphabet=", quote(strings.Join(letters, ",")), ";\n")
+	ret += fmt.Sprintln("\tnode [shape=circle];")
+
+	for i, q := range g.states {
+		attributes := make([]string, 0)
+		if g.final[i] == true {
+			attributes = append(attributes, "shape=doublecircle")
+		}
+		if g.highlightedStates[i] == true {
+			attributes = append(attributes, "color=red", "penwidth=2")
+		}
+
+		if len(attributes) > 0 {
+			ret += fmt.Sprint("\t", quote(string(q)), " [", strings.Join(attributes, ", "), "];\n")
+		} else {
+			ret += fmt.Sprint("\t", quote(string(q)), ";\n")
+		}
+	}
+
+	prefix := g.freshPrefix("__start")
+	for k, i := range g.initial {
+		start := fmt.Sprint(prefix, k)
+		ret += fmt.Sprint("\t", quote(start), " [shape=point, style=invis];\n")
+		ret += fmt.Sprint("\t", quote(start), " -> ", quote(string(g.states[i])), ";\n")
+	}
+
+	for _, e := range g.edges {
+		labels := make([]string, len(e.labels))
+		for k, a := range e.labels {
+			if e.epsilon == true && k == len(e.labels)-1 {
+				labels[k] = a
+			} else {
+				labels[k] = escapeLetter(a)
+			}
+		}
+		attributes := []string{"label=" + quote(strings.Join(labels, ","))}
+		if e.highlighted == true {
+			attributes = append(attributes, "color=red", "penwidth=2")
+		}
+		ret += fmt.Sprint("\t", quote(string(g.states[e.from])), " -> ", quote(string(g.states[e.to])), " [", strings.Join(attributes, ", "), "];\n")
+	}
+
+	ret += fmt.Sprintln("}")
+
+	_, err := io.WriteString(w, ret)
+	return err
+}
+
+// a tikzpicture for the TikZ automata library
+// the states are placed in a row, nodes are named q0, q1, ... in the order of A.States().
+func TikZ(w io.Writer, A nfa.Nfa, opts Options) error {
+	g := newGraph(A, opts)
+
+	escape := func(s string) string {
+		replacer := strings.NewReplacer(
+			`\`, `\textbackslash{}`,
+			`{`, `\{`,
+			`}`, `\}`,
+			`$`, `\$`,
+			`&`, `\&`,
+			`#`, `\#`,
+			`%`, `\%`,
+			`_`, `\_`,
+			`^`, `\textasciicircum{}`,
+			`~`, `\textasciitilde{}`,
+			Epsilon, `$\varepsilon$`,
+		)
+		return replacer.Replace(s)
+	}
+
+	ret := ""
+	ret += fmt.Sprintln(`% requires \usetikzlibrary{automata,positioning}`)
+	ret += fmt.Sprintln(`\begin{tikzpicture}[->, >=stealth, auto, node distance=2cm]`)
+
+	for i, q := range g.states {
+		attributes := []string{"state"}
+		if g.isInitial(i) == true {
+			attributes = append(attributes, "initial")
+		}
+		if g.final[i] == true {
+			attributes = append(attributes, "accepting")
+		}
+		if g.highlightedStates[i] == true {
+			attributes = append(attributes, "draw=red", "thick")
+		}
+		if i > 0 {
+			attributes = append(attributes, fmt.Sprint("right=of q", i-1))
+		}
+		ret += fmt.Sprint("\t\\node[", strings.Join(attributes, ", "), "] (q", i, ") {", escape(string(q)), "};\n")
+	}
+
+	if len(g.edges) > 0 {
+		ret += fmt.Sprintln("\t\\path")
+		for k, e := range g.edges {
+			attributes := make([]string, 0)
+			if e.from == e.to {
+				attributes = append(attributes, "loop above")
+			} else if g.hasEdge(e.to, e.from) == true {
+				attributes = append(attributes, "bend left")
+			}
+			if e.highlighted == true {
+				attributes = append(attributes, "red", "thick")
+			}
+
+			edge := "edge"
+			if len(attributes) > 0 {
+				edge += " [" + strings.Join(attributes, ", ") + "]"
+			}
+
+			end := ""
+			if k == len(g.edges)-1 {
+				end = ";"
+			}
+			ret += fmt.Sprint("\t\t(q", e.from, ") ", edge, " node {", escape(strings.Join(e.labels, ",")), "} (q", e.to, ")", end, "\n")
+		}
+	}
+
+	ret += fmt.Sprintln(`\end{tikzpicture}`)
+
+	_, err := io.WriteString(w, ret)
+	return err
+}
+
+// a Mermaid state diagram
+// states are named s0, s1, ... in the order of A.States(). final states get the class final.
+// transitions cannot be styled in state diagrams, hence only the states of the run are highlighted.
+func Mermaid(w io.Writer, A nfa.Nfa, opts Options) error {
+	g := newGraph(A, opts)
+
+	quote := func(s string) string {
+		return `"` + strings.Replace(s, `"`, "#quot;", -1) + `"`
+	}
+
+	ret := ""
+	ret += fmt.Sprintln("stateDiagram-v2")
+	ret += fmt.Sprintln("\tdirection LR")
+
+	for i, q := range g.states {
+		ret += fmt.Sprint("\tstate ", quote(string(q)), " as s", i, "\n")
+	}
+
+	for _, i := range g.initial {
+		ret += fmt.Sprint("\t[*] --> s", i, "\n")
+	}
+
+	for _, e := range g.edges {
+		ret += fmt.Sprint("\ts", e.from, " --> s", e.to, " : ", strings.Join(e.labels, ","), "\n")
+	}
+
+	class := func(name, style string, members []bool) {
+		states := make([]string, 0)
+		for i := range g.states {
+			if members[i] == true {
+				states = append(states, fmt.Sprint("s", i))
+			}
+		}
+		if len(states) > 0 {
+			ret += fmt.Sprint("\tclassDef ", name, " ", style, "\n")
+			ret += fmt.Sprint("\tclass ", strings.Join(states, ","), " ", name, "\n")
+		}
+	}
+	class("final", "stroke-width:4px,font-weight:bold", g.final)
+	class("highlighted", "stroke:#f00,stroke-width:3px", g.highlightedStates)
+
+	_, err := io.WriteString(w, ret)
+	return err
+}
+
+/*****************************************************************************/
+
+// the automaton with merged parallel edges, states are referred to by their index in A.States()
+type graph struct {
+	name              string
+	states            []nfa.State
+	initial           []int
+	final             []bool
+	edges             []edge
+	highlightedStates []bool
+}
+
+// labels are letters, followed by Epsilon if epsilon is set
+type edge struct {
+	from, to    int
+	labels      []string
+	epsilon     bool
+	highlighted bool
+}
+
+func newGraph(A nfa.Nfa, opts Options) graph {
+	g := graph{opts.Name, make([]nfa.State, A.States().Size()), make([]int, 0), make([]bool, A.States().Size()), make([]edge, 0), make([]bool, A.States().Size())}
+	if g.name == "" {
+		g.name = "A"
+	}
+
+	index := make(map[nfa.State]int)
+	for i := 0; i < A.States().Size(); i += 1 {
+		q, _ := A.States().At(i)
+		g.states[i] = q.(nfa.State)
+		index[q.(nfa.State)] = i
+
+		if A.InitialStates().Probe(q) == true {
+			g.initial = append(g.initial, i)
+		}
+		g.final[i] = A.FinalStates().Probe(q)
+	}
+
+	highlightedEdges := make(map[[2]int]bool)
+	for k, q := range opts.Run {
+		if i, ok := index[q]; ok == true {
+			g.highlightedStates[i] = true
+			if k+1 < len(opts.Run) {
+				if j, ok := index[opts.Run[k+1]]; ok == true {
+					highlightedEdges[[2]int{i, j}] = true
+				}
+			}
+		}
+	}
+
+	for i, q := range g.states {
+		// targets in the order they are found
+		targets := make([]int, 0)
+		labels := make(map[int][]string)
+
+		add := func(R nfa.StateSet, label string) {
+			for k := 0; k < R.Size(); k += 1 {
+				r, _ := R.At(k)
+				j := index[r.(nfa.State)]
+				if _, ok := labels[j]; ok == false {
+					targets = append(targets, j)
+				}
+				labels[j] = append(labels[j], label)
+			}
+		}
+
+		for k := 0; k < A.Alphabet().Size(); k += 1 {
+			a, _ := A.Alphabet().At(k)
+			add(A.Transition(q, a.(nfa.Letter)), string(a.(nfa.Letter)))
+		}
+		add(A.EpsilonTransition(q), Epsilon)
+
+		for _, j := range targets {
+			epsilon := A.EpsilonTransition(q).Probe(g.states[j])
+			g.edges = append(g.edges, edge{i, j, labels[j], epsilon, highlightedEdges[[2]int{i, j}]})
+		}
+	}
+
+	return g
+}
+
+// backslash, comma and white space are escaped with a backslash, as is the whole letter if it reads ε
+func escapeLetter(a string) string {
+	if a == Epsilon {
+		return `\` + a
+	}
+	ret := ""
+	for _, c := range a {
+		if c == '\\' || c == ',' || unicode.IsSpace(c) == true {
+			ret += `\`
+		}
+		ret += string(c)
+	}
+	return ret
+}
+
+func (g graph) isInitial(i int) bool {
+	for _, j := range g.initial {
+		if i == j {
+			return true
+		}
+	}
+	return false
+}
+
+// a prefix which no state name starts with, for the names of auxiliary nodes
+func (g graph) freshPrefix(prefix string) string {
+	for i := 0; i < len(g.states); i += 1 {
+		if strings.HasPrefix(string(g.states[i]), prefix) == true {
+			prefix = "_" + prefix
+			i = -1
+		}
+	}
+	return prefix
+}
+
+func (g graph) hasEdge(i, j int) bool {
+	for _, e := range g.edges {
+		if e.from == i && e.to == j {
+			return true
+		}
+	}
+	return false
+}
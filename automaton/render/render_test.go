@@ -0,0 +1,176 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"testing"
+)
+
+// --> 0 -- a,b --> 1 -- ε --> |2|, and a loop 0 -- a --> 0
+var example = []byte(`{"States":["0","1","2"],"Alphabet":["a","b"],"InitialStates":["0"],"Transitions":{"0":{"a":["0","1"],"b":["1"]}},"EpsilonTransitions":{"1":["2"]},"FinalStates":["2"]}`)
+
+func TestDot(t *testing.T) {
+	A := nfa.NewNfa()
+	json.Unmarshal(example, &A)
+
+	var b bytes.Buffer
+	if err := Dot(&b, A, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `digraph "A" {
+	rankdir=LR;
+	alphabet="a,b";
+	node [shape=circle];
+	"0";
+	"1";
+	"2" [shape=doublecircle];
+	"__start0" [shape=point, style=invis];
+	"__start0" -> "0";
+	"0" -> "0" [label="a"];
+	"0" -> "1" [label="a,b"];
+	"1" -> "2" [label="ε"];
+}
+`
+	if b.String() != expected {
+		t.Error(b.String())
+	}
+}
+
+// the start nodes do not clash with states
+func TestDotStartNodes(t *testing.T) {
+	A := nfa.NewNfa()
+	json.Unmarshal([]byte(`{"States":["__start0","x"],"Alphabet":[],"InitialStates":["x"],"Transitions":{},"FinalStates":[]}`), &A)
+
+	var b bytes.Buffer
+	Dot(&b, A, Options{})
+	if bytes.Contains(b.Bytes(), []byte(`"___start0" -> "x";`)) == false || bytes.Contains(b.Bytes(), []byte(`"__start0" -> "x";`)) == true {
+		t.Error(b.String())
+	}
+}
+
+func TestDotRun(t *testing.T) {
+	A := nfa.NewNfa()
+	json.Unmarshal(example, &A)
+
+	run, err := A.AcceptingRun([]nfa.Letter{"b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	Dot(&b, A, Options{Name: "run \"b\"", Run: run})
+
+	expected := `digraph "run \"b\"" {
+	rankdir=LR;
+	alphabet="a,b";
+	node [shape=circle];
+	"0" [color=red, penwidth=2];
+	"1" [color=red, penwidth=2];
+	"2" [shape=doublecircle, color=red, penwidth=2];
+	"__start0" [shape=point, style=invis];
+	"__start0" -> "0";
+	"0" -> "0" [label="a"];
+	"0" -> "1" [label="a,b", color=red, penwidth=2];
+	"1" -> "2" [label="ε", color=red, penwidth=2];
+}
+`
+	if b.String() != expected {
+		t.Error(b.String())
+	}
+}
+
+func TestTikZ(t *testing.T) {
+	A := nfa.NewNfa()
+	json.Unmarshal(example, &A)
+
+	var b bytes.Buffer
+	if err := TikZ(&b, A, Options{Run: []nfa.State{"0", "0"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `% requires \usetikzlibrary{automata,positioning}
+\begin{tikzpicture}[->, >=stealth, auto, node distance=2cm]
+	\node[state, initial, draw=red, thick] (q0) {0};
+	\node[state, right=of q0] (q1) {1};
+	\node[state, accepting, right=of q1] (q2) {2};
+	\path
+		(q0) edge [loop above, red, thick] node {a} (q0)
+		(q0) edge node {a,b} (q1)
+		(q1) edge node {$\varepsilon$} (q2);
+\end{tikzpicture}
+`
+	if b.String() != expected {
+		t.Error(b.String())
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	A := nfa.NewNfa()
+	json.Unmarshal(example, &A)
+
+	var b bytes.Buffer
+	if err := Mermaid(&b, A, Options{Run: []nfa.State{"0", "1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `stateDiagram-v2
+	direction LR
+	state "0" as s0
+	state "1" as s1
+	state "2" as s2
+	[*] --> s0
+	s0 --> s0 : a
+	s0 --> s1 : a,b
+	s1 --> s2 : ε
+	classDef final stroke-width:4px,font-weight:bold
+	class s2 final
+	classDef highlighted stroke:#f00,stroke-width:3px
+	class s0,s1 highlighted
+`
+	if b.String() != expected {
+		t.Error(b.String())
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	A := nfa.NewNfa()
+	A.States().Add(nfa.State(`"q"_1`))
+	A.InitialStates().Add(nfa.State(`"q"_1`))
+
+	var b bytes.Buffer
+	Dot(&b, A, Options{})
+	if bytes.Contains(b.Bytes(), []byte(`"\"q\"_1"`)) == false {
+		t.Error(b.String())
+	}
+
+	B := A.Copy()
+	B.Alphabet().Add(nfa.Letter(`a,\ b`))
+	B.Alphabet().Add(nfa.Letter(Epsilon))
+	B.SetTransition(`"q"_1`, `a,\ b`, B.States())
+	B.SetTransition(`"q"_1`, Epsilon, B.States())
+	B.SetEpsilonTransition(`"q"_1`, B.States())
+	b.Reset()
+	Dot(&b, B, Options{})
+	if bytes.Contains(b.Bytes(), []byte(`alphabet="a\\,\\\\\\ b,\\ε";`)) == false || bytes.Contains(b.Bytes(), []byte(`[label="a\\,\\\\\\ b,\\ε,ε"]`)) == false {
+		t.Error(b.String())
+	}
+
+	B.Alphabet().Add(nfa.Letter(""))
+	if err := Dot(&b, B, Options{}); err == nil {
+		t.Error(err)
+	}
+
+	b.Reset()
+	TikZ(&b, A, Options{})
+	if bytes.Contains(b.Bytes(), []byte(`{"q"\_1}`)) == false {
+		t.Error(b.String())
+	}
+
+	b.Reset()
+	Mermaid(&b, A, Options{})
+	if bytes.Contains(b.Bytes(), []byte(`state "#quot;q#quot;_1" as s0`)) == false {
+		t.Error(b.String())
+	}
+}
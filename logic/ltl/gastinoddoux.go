@@ -0,0 +1,618 @@
+package ltl
+
+import (
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/buchi"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"github.com/hydroo/gomochex/basic/set"
+	"sort"
+	"strings"
+)
+
+type Translator int
+
+const (
+	// Gerth, Peled, Vardi, Wolper, see ToGBA
+	Tableau Translator = iota
+	// Gastin, Oddoux, via a very weak alternating automaton, see ToGBAViaVWAA
+	AlternatingAutomaton
+)
+
+type Options struct {
+	Translator Translator
+}
+
+// Büchi automaton which accepts exactly the words that satisfy phi, see Valuation for the alphabet.
+// the generalized Büchi automaton of the chosen translator is trimmed, degeneralized and
+// reduced by merging bisimilar states.
+func ToBuchi(phi Formula, opts Options) buchi.Buchi {
+	var G buchi.Gba
+	switch opts.Translator {
+	case Tableau:
+		G = ToGBA(phi)
+	case AlternatingAutomaton:
+		G = ToGBAViaVWAA(phi)
+	default:
+		panic(fmt.Sprint("unknown translator ", opts.Translator))
+	}
+
+	// FromNfa only fails for epsilon transitions, which neither the Büchi automaton nor its quotient have
+	B, err := buchi.FromNfa(nfa.QuotientByBisimulation(buchi.Degeneralize(buchi.Trim(G)).Nfa()))
+	if err != nil {
+		panic(err)
+	}
+	return B
+}
+
+// generalized Büchi automaton which accepts exactly the words that satisfy phi, see Valuation for the alphabet.
+//
+// Gastin, Oddoux: Fast LTL to Büchi automata translation.
+// phi in negation normal form is translated into a very weak alternating automaton whose states
+// are the temporal subformulas. its conjunctions of states are the states of a transition-based
+// generalized Büchi automaton with an acceptance set for every until. that one is made state-based
+// by remembering the acceptance sets of the last transition.
+// transitions which are implied by others are removed, states with equal transitions are merged,
+// and only states from which some word is accepted are kept.
+func ToGBAViaVWAA(phi Formula) buchi.Gba {
+	phi = negationNormalForm(phi)
+
+	props := aps(phi)
+	letters := make([][]string, 1)
+	letters[0] = make([]string, 0)
+	for _, p := range props {
+		for _, l := range letters {
+			letters = append(letters, append(append(make([]string, 0), l...), p))
+		}
+	}
+
+	A := newVwaa(phi, props)
+	T := newTgba(A, untils(phi))
+	T.mergeEquivalentStates()
+
+	// the states of the state-based automaton are pairs of a state of T and the acceptance sets
+	// of the incoming transition
+	type node struct {
+		q          int
+		acceptance string
+	}
+
+	nodes := make([]node, 0)
+	acceptanceOf := make([]bits, 0)
+	id := make(map[node]int)
+	number := func(q int, acceptance bits) int {
+		v := node{q, acceptance.key()}
+		if k, ok := id[v]; ok == true {
+			return k
+		}
+		id[v] = len(nodes)
+		nodes = append(nodes, v)
+		acceptanceOf = append(acceptanceOf, acceptance)
+		return len(nodes) - 1
+	}
+	name := func(k int) nfa.State {
+		return nfa.State(fmt.Sprint(k))
+	}
+
+	G := buchi.NewGba()
+	for _, l := range letters {
+		G.Alphabet().Add(Valuation(l...))
+	}
+
+	for _, q := range T.initial {
+		k := number(q, newBits(len(T.acceptance)))
+		G.States().Add(name(k))
+		G.InitialStates().Add(name(k))
+	}
+	for k := 0; k < len(nodes); k += 1 {
+		targets := make([]nfa.StateSet, len(letters))
+		for i := range targets {
+			targets[i] = set.NewSet()
+		}
+		for _, t := range T.transitions[nodes[k].q] {
+			l := number(t.target, t.acceptance)
+			G.States().Add(name(l))
+			for i := range letters {
+				if t.label.probe(i) == true {
+					targets[i].Add(name(l))
+				}
+			}
+		}
+		for i, l := range letters {
+			G.SetTransition(name(k), Valuation(l...), targets[i])
+		}
+	}
+
+	// acceptance sets which contain every transition are dropped
+	acceptanceSets := make([]nfa.StateSet, 0)
+	for i := range T.acceptance {
+		F := set.NewSet()
+		for k := range nodes {
+			if acceptanceOf[k].probe(i) == true {
+				F.Add(name(k))
+			}
+		}
+		if T.acceptsAllTransitions(i) == false {
+			acceptanceSets = append(acceptanceSets, F)
+		}
+	}
+	G.SetAcceptanceSets(acceptanceSets)
+
+	return buchi.Trim(G)
+}
+
+/*****************************************************************************/
+
+// a set of small non-negative integers: letters, given by their index in the list of
+// valuations, or acceptance sets
+type bits []uint64
+
+func newBits(n int) bits {
+	return make(bits, (n+63)/64)
+}
+
+// {0, ..., n-1}
+func allBits(n int) bits {
+	ret := newBits(n)
+	for i := 0; i < n; i += 1 {
+		ret.add(i)
+	}
+	return ret
+}
+
+func (b bits) copy() bits {
+	return append(make(bits, 0, len(b)), b...)
+}
+
+func (b bits) add(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+func (b bits) probe(i int) bool {
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+func (b bits) and(c bits) bits {
+	ret := make(bits, len(b))
+	for i := range b {
+		ret[i] = b[i] & c[i]
+	}
+	return ret
+}
+
+func (b bits) or(c bits) bits {
+	ret := make(bits, len(b))
+	for i := range b {
+		ret[i] = b[i] | c[i]
+	}
+	return ret
+}
+
+func (b bits) andNot(c bits) bits {
+	ret := make(bits, len(b))
+	for i := range b {
+		ret[i] = b[i] &^ c[i]
+	}
+	return ret
+}
+
+func (b bits) isEmpty() bool {
+	for _, x := range b {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b bits) isSubsetOf(c bits) bool {
+	return b.andNot(c).isEmpty()
+}
+
+func (b bits) key() string {
+	return fmt.Sprint([]uint64(b))
+}
+
+// a conjunction of states of the alternating automaton, sorted
+type conjunction []string
+
+func (e conjunction) union(f conjunction) conjunction {
+	seen := make(map[string]bool)
+	ret := make(conjunction, 0, len(e)+len(f))
+	for _, q := range append(append(make(conjunction, 0), e...), f...) {
+		if seen[q] == false {
+			seen[q] = true
+			ret = append(ret, q)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (e conjunction) probe(q string) bool {
+	i := sort.SearchStrings(e, q)
+	return i < len(e) && e[i] == q
+}
+
+func (e conjunction) isSubsetOf(f conjunction) bool {
+	for _, q := range e {
+		if f.probe(q) == false {
+			return false
+		}
+	}
+	return true
+}
+
+func (e conjunction) key() string {
+	return strings.Join(e, "\n")
+}
+
+/*****************************************************************************/
+
+// very weak alternating automaton: from every state, the transitions only lead back to the
+// same state or to smaller subformulas
+type vwaa struct {
+	props       []string // bit i of a letter is the i-th proposition
+	letters     int
+	initial     []conjunction
+	states      map[string]Formula
+	transitions map[string][]vwaaTransition
+}
+
+// reading a letter of label leads to all states of targets
+type vwaaTransition struct {
+	label   bits
+	targets conjunction
+}
+
+func newVwaa(phi Formula, props []string) *vwaa {
+	A := &vwaa{props, 1 << uint(len(props)), nil, make(map[string]Formula), make(map[string][]vwaaTransition)}
+	A.initial = A.conjunctions(phi)
+	return A
+}
+
+// the disjunctive normal form of phi over the states, which are the temporal subformulas and literals
+func (A *vwaa) conjunctions(phi Formula) []conjunction {
+	switch f := phi.(type) {
+	case trueFormula:
+		return []conjunction{conjunction{}}
+	case falseFormula:
+		return []conjunction{}
+	case andFormula:
+		ret := make([]conjunction, 0)
+		for _, e := range A.conjunctions(f.phi) {
+			for _, g := range A.conjunctions(f.psi) {
+				ret = append(ret, e.union(g))
+			}
+		}
+		return minimalConjunctions(ret)
+	case orFormula:
+		return minimalConjunctions(append(A.conjunctions(f.phi), A.conjunctions(f.psi)...))
+	}
+	A.states[phi.String()] = phi
+	return []conjunction{conjunction{phi.String()}}
+}
+
+// the transitions of the state q
+func (A *vwaa) delta(q string) []vwaaTransition {
+	if ret, ok := A.transitions[q]; ok == true {
+		return ret
+	}
+	ret := A.deltaOf(A.states[q])
+	A.transitions[q] = ret
+	return ret
+}
+
+func (A *vwaa) deltaOf(phi Formula) []vwaaTransition {
+	all := allBits(A.letters)
+	self := []vwaaTransition{vwaaTransition{all, conjunction{phi.String()}}}
+
+	switch f := phi.(type) {
+	case trueFormula:
+		return []vwaaTransition{vwaaTransition{all, conjunction{}}}
+	case falseFormula:
+		return []vwaaTransition{}
+	case aPFormula, notFormula:
+		label := newBits(A.letters)
+		for l := 0; l < A.letters; l += 1 {
+			if literalHolds(phi, l, A.props) == true {
+				label.add(l)
+			}
+		}
+		return []vwaaTransition{vwaaTransition{label, conjunction{}}}
+	case andFormula:
+		return product(A.deltaOf(f.phi), A.deltaOf(f.psi))
+	case orFormula:
+		return minimalTransitions(append(A.deltaOf(f.phi), A.deltaOf(f.psi)...))
+	case nextFormula:
+		ret := make([]vwaaTransition, 0)
+		for _, e := range A.conjunctions(f.phi) {
+			ret = append(ret, vwaaTransition{all, e})
+		}
+		return ret
+	case untilFormula:
+		A.states[phi.String()] = phi
+		return minimalTransitions(append(A.deltaOf(f.psi), product(A.deltaOf(f.phi), self)...))
+	case releaseFormula:
+		A.states[phi.String()] = phi
+		return product(A.deltaOf(f.psi), minimalTransitions(append(A.deltaOf(f.phi), self...)))
+	}
+
+	panic(fmt.Sprint("unexpected formula ", phi, " in negation normal form"))
+}
+
+// whether the literal holds for the letter, bit i of a letter is the i-th proposition
+func literalHolds(phi Formula, letter int, props []string) bool {
+	holds := func(a string) bool {
+		for i, p := range props {
+			if p == a {
+				return letter&(1<<uint(i)) != 0
+			}
+		}
+		return false
+	}
+
+	if f, ok := phi.(aPFormula); ok == true {
+		return holds(f.a)
+	}
+	return holds(phi.(notFormula).phi.(aPFormula).a) == false
+}
+
+// the pairwise conjunctions
+func product(s, t []vwaaTransition) []vwaaTransition {
+	ret := make([]vwaaTransition, 0)
+	for _, x := range s {
+		for _, y := range t {
+			if label := x.label.and(y.label); label.isEmpty() == false {
+				ret = append(ret, vwaaTransition{label, x.targets.union(y.targets)})
+			}
+		}
+	}
+	return minimalTransitions(ret)
+}
+
+// removes the transitions which are implied by another one with a larger label and fewer targets
+func minimalTransitions(s []vwaaTransition) []vwaaTransition {
+	ret := make([]vwaaTransition, 0)
+	for i, x := range s {
+		implied := x.label.isEmpty()
+		for j, y := range s {
+			if i != j && x.label.isSubsetOf(y.label) == true && y.targets.isSubsetOf(x.targets) == true {
+				// of two equal transitions the first one is kept
+				if y.label.isSubsetOf(x.label) == false || x.targets.isSubsetOf(y.targets) == false || j < i {
+					implied = true
+					break
+				}
+			}
+		}
+		if implied == false {
+			ret = append(ret, x)
+		}
+	}
+	return ret
+}
+
+// removes the supersets of other conjunctions
+func minimalConjunctions(s []conjunction) []conjunction {
+	ret := make([]conjunction, 0)
+	for i, e := range s {
+		implied := false
+		for j, f := range s {
+			if i != j && f.isSubsetOf(e) == true && (e.isSubsetOf(f) == false || j < i) {
+				implied = true
+				break
+			}
+		}
+		if implied == false {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+/*****************************************************************************/
+
+// transition-based generalized Büchi automaton, the states are 0, 1, ...
+type tgba struct {
+	initial     []int
+	transitions [][]tgbaTransition
+	acceptance  []untilFormula
+}
+
+type tgbaTransition struct {
+	label      bits
+	target     int
+	acceptance bits
+}
+
+// the states are the reachable conjunctions of states of A.
+// a transition is in the acceptance set of an until if it does not lead to the until, or if
+// it fulfills the until with a transition of the until that leads to fewer states.
+func newTgba(A *vwaa, acceptance []untilFormula) *tgba {
+	T := &tgba{make([]int, 0), make([][]tgbaTransition, 0), acceptance}
+
+	states := make([]conjunction, 0)
+	id := make(map[string]int)
+	number := func(e conjunction) int {
+		if k, ok := id[e.key()]; ok == true {
+			return k
+		}
+		id[e.key()] = len(states)
+		states = append(states, e)
+		T.transitions = append(T.transitions, nil)
+		return len(states) - 1
+	}
+
+	for _, e := range A.initial {
+		T.initial = append(T.initial, number(e))
+	}
+
+	type transition struct {
+		label      bits
+		targets    conjunction
+		acceptance bits
+	}
+
+	for k := 0; k < len(states); k += 1 {
+		delta := []vwaaTransition{vwaaTransition{allBits(A.letters), conjunction{}}}
+		for _, q := range states[k] {
+			delta = product(delta, A.delta(q))
+		}
+
+		ts := make([]transition, 0)
+		for _, t := range delta {
+			ts = append(ts, transition{t.label, t.targets, newBits(len(acceptance))})
+		}
+
+		// transitions are split into the letters which fulfill an until and those which do not
+		for i, u := range acceptance {
+			f := u.String()
+			split := make([]transition, 0)
+			for _, t := range ts {
+				if t.targets.probe(f) == false {
+					t.acceptance = t.acceptance.copy()
+					t.acceptance.add(i)
+					split = append(split, t)
+					continue
+				}
+
+				fulfilling := newBits(A.letters)
+				if _, ok := A.states[f]; ok == true {
+					for _, s := range A.delta(f) {
+						if s.targets.probe(f) == false && s.targets.isSubsetOf(t.targets) == true {
+							fulfilling = fulfilling.or(s.label)
+						}
+					}
+				}
+
+				if label := t.label.and(fulfilling); label.isEmpty() == false {
+					acceptance := t.acceptance.copy()
+					acceptance.add(i)
+					split = append(split, transition{label, t.targets, acceptance})
+				}
+				if label := t.label.andNot(fulfilling); label.isEmpty() == false {
+					split = append(split, transition{label, t.targets, t.acceptance})
+				}
+			}
+			ts = split
+		}
+
+		// a transition is implied by one with a larger label, fewer targets and more acceptance sets
+		minimal := make([]transition, 0)
+		for i, x := range ts {
+			implied := false
+			for j, y := range ts {
+				if i != j && x.label.isSubsetOf(y.label) == true && y.targets.isSubsetOf(x.targets) == true && x.acceptance.isSubsetOf(y.acceptance) == true {
+					if y.label.isSubsetOf(x.label) == false || x.targets.isSubsetOf(y.targets) == false || y.acceptance.isSubsetOf(x.acceptance) == false || j < i {
+						implied = true
+						break
+					}
+				}
+			}
+			if implied == false {
+				minimal = append(minimal, x)
+			}
+		}
+
+		for _, t := range minimal {
+			T.transitions[k] = append(T.transitions[k], tgbaTransition{t.label, number(t.targets), t.acceptance})
+		}
+		T.transitions[k] = mergeLabels(T.transitions[k])
+	}
+
+	return T
+}
+
+// joins the labels of transitions with the same target and acceptance sets
+func mergeLabels(ts []tgbaTransition) []tgbaTransition {
+	ret := make([]tgbaTransition, 0)
+	position := make(map[string]int)
+	for _, t := range ts {
+		k := fmt.Sprint(t.target, t.acceptance.key())
+		if p, ok := position[k]; ok == true {
+			ret[p].label = ret[p].label.or(t.label)
+		} else {
+			position[k] = len(ret)
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// merges the states which are bisimilar with respect to labels and acceptance sets.
+// the classes are refined by their transitions until nothing changes,
+// every class is represented by its smallest state.
+func (T *tgba) mergeEquivalentStates() {
+	n := len(T.transitions)
+	class := make([]int, n)
+	count := 1
+
+	signature := func(k int) string {
+		ts := make([]string, 0)
+		for _, t := range mergeLabels(T.relabeled(k, class)) {
+			ts = append(ts, fmt.Sprint(t.label.key(), t.target, t.acceptance.key()))
+		}
+		sort.Strings(ts)
+		return fmt.Sprint(class[k], ts)
+	}
+
+	for {
+		refined := make([]int, n)
+		classes := make(map[string]int)
+		for k := 0; k < n; k += 1 {
+			s := signature(k)
+			if _, ok := classes[s]; ok == false {
+				classes[s] = len(classes)
+			}
+			refined[k] = classes[s]
+		}
+		class = refined
+		if len(classes) == count {
+			break
+		}
+		count = len(classes)
+	}
+
+	representative := make(map[int]int)
+	for k := 0; k < n; k += 1 {
+		if _, ok := representative[class[k]]; ok == false {
+			representative[class[k]] = k
+		}
+	}
+	for k := 0; k < n; k += 1 {
+		class[k] = representative[class[k]]
+	}
+
+	for k := 0; k < n; k += 1 {
+		T.transitions[k] = mergeLabels(T.relabeled(k, class))
+	}
+	initial := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, q := range T.initial {
+		if seen[class[q]] == false {
+			seen[class[q]] = true
+			initial = append(initial, class[q])
+		}
+	}
+	T.initial = initial
+}
+
+// the transitions of k with the targets renamed
+func (T *tgba) relabeled(k int, name []int) []tgbaTransition {
+	ret := make([]tgbaTransition, len(T.transitions[k]))
+	for i, t := range T.transitions[k] {
+		ret[i] = tgbaTransition{t.label, name[t.target], t.acceptance}
+	}
+	return ret
+}
+
+// whether every transition is in the i-th acceptance set
+func (T *tgba) acceptsAllTransitions(i int) bool {
+	for _, ts := range T.transitions {
+		for _, t := range ts {
+			if t.acceptance.probe(i) == false {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package ltl
+
+import (
+	"github.com/hydroo/gomochex/automaton/buchi"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"math/rand"
+	"testing"
+)
+
+func TestToBuchi(t *testing.T) {
+	a, b, none := Valuation("a"), Valuation("b"), Valuation()
+
+	type test struct {
+		phi          string
+		prefix, loop []nfa.Letter
+		accepted     bool
+	}
+
+	tests := []test{
+		test{"□(◇(a))", nil, []nfa.Letter{a, none}, true},
+		test{"□(◇(a))", []nfa.Letter{a}, []nfa.Letter{none}, false},
+		test{"◇(□(a))", []nfa.Letter{none}, []nfa.Letter{a}, true},
+		test{"((a)U(b))", []nfa.Letter{a}, []nfa.Letter{b}, true},
+		test{"((a)U(b))", nil, []nfa.Letter{a}, false},
+		test{"□((a∨○(b)))", nil, []nfa.Letter{a, b}, false},
+		test{"□((a∨○(b)))", nil, []nfa.Letter{a, b, Valuation("a", "b")}, true},
+		test{"false", nil, []nfa.Letter{none}, false},
+	}
+
+	for k, x := range tests {
+		phi, _ := FormulaFromString(x.phi)
+		prefix, loop := project(x.prefix, aps(phi)), project(x.loop, aps(phi))
+		for _, translator := range []Translator{Tableau, AlternatingAutomaton} {
+			B := ToBuchi(phi, Options{translator})
+			if B.Validate() != nil || B.AcceptsLasso(prefix, loop) != x.accepted {
+				t.Error("case", k, translator, B)
+			}
+		}
+		if G := ToGBAViaVWAA(phi); G.Validate() != nil || G.AcceptsLasso(prefix, loop) != x.accepted {
+			t.Error("case", k, G)
+		}
+	}
+
+	// one state waits for a, the other one has just seen it
+	phi, _ := FormulaFromString("□(◇(a))")
+	if B := ToBuchi(phi, Options{AlternatingAutomaton}); B.States().Size() != 2 || B.AcceptingStates().Size() != 1 {
+		t.Error(B)
+	}
+	if B := ToBuchi(False(), Options{AlternatingAutomaton}); B.States().Size() != 0 {
+		t.Error(B)
+	}
+}
+
+func TestToGBAViaVWAAAgainstBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	props := []string{"a", "b", "c"}
+
+	randomWord := func(n int) [][]string {
+		ret := make([][]string, n)
+		for i := range ret {
+			ret[i] = make([]string, 0)
+			for _, p := range props {
+				if rng.Intn(2) == 0 {
+					ret[i] = append(ret[i], p)
+				}
+			}
+		}
+		return ret
+	}
+
+	accepted, rejected := 0, 0
+	for k := 0; k < 300; k += 1 {
+		phi := randomFormula(rng, props, 5)
+		G := ToGBAViaVWAA(phi)
+		B := ToBuchi(phi, Options{AlternatingAutomaton})
+		C := ToBuchi(phi, Options{Tableau})
+
+		for l := 0; l < 20; l += 1 {
+			prefix, loop := randomWord(rng.Intn(4)), randomWord(1+rng.Intn(4))
+			expected := holds(phi, prefix, loop)
+			if expected == true {
+				accepted += 1
+			} else {
+				rejected += 1
+			}
+
+			u, v := project(letters(prefix), aps(phi)), project(letters(loop), aps(phi))
+			if G.AcceptsLasso(u, v) != expected || B.AcceptsLasso(u, v) != expected || C.AcceptsLasso(u, v) != expected {
+				t.Error("case", k, phi, prefix, loop, expected)
+			}
+		}
+	}
+
+	if accepted < 1000 || rejected < 1000 {
+		t.Error(accepted, rejected)
+	}
+}
+
+// the tableau has a node for every combination of pending untils
+func TestToGBAViaVWAASize(t *testing.T) {
+	phi := True()
+	for _, p := range []string{"a", "b", "c", "d"} {
+		phi = And(phi, Always(Eventually(Ap(p))))
+	}
+
+	tableau := buchi.Trim(ToGBA(phi)).States().Size()
+	alternating := ToGBAViaVWAA(phi).States().Size()
+	if alternating >= tableau {
+		t.Error(alternating, tableau)
+	}
+}
@@ -0,0 +1,389 @@
+package ltl
+
+import (
+	"fmt"
+	"github.com/hydroo/gomochex/automaton/buchi"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"github.com/hydroo/gomochex/basic/set"
+	"sort"
+	"strings"
+)
+
+// the letter for the valuation in which exactly the given atomic propositions hold, e.g. "{a,b}".
+// the propositions are sorted, the empty valuation is "{}".
+func Valuation(aps ...string) nfa.Letter {
+	sorted := append(make([]string, 0, len(aps)), aps...)
+	sort.Strings(sorted)
+	return nfa.Letter("{" + strings.Join(sorted, ",") + "}")
+}
+
+// generalized Büchi automaton which accepts exactly the words that satisfy phi.
+// the alphabet consists of the valuations of the atomic propositions of phi, see Valuation.
+//
+// Gerth, Peled, Vardi, Wolper: Simple on-the-fly automatic verification of linear temporal logic.
+// phi is brought into negation normal form, then the tableau nodes are expanded until only
+// literals are left. a node's literals label its incoming transitions. there is an acceptance set
+// for every until subformula, containing the nodes which do not promise it or which fulfill it.
+func ToGBA(phi Formula) buchi.Gba {
+	phi = negationNormalForm(phi)
+
+	props := aps(phi)
+	letters := make([][]string, 1)
+	letters[0] = make([]string, 0)
+	for _, p := range props {
+		for _, l := range letters {
+			letters = append(letters, append(append(make([]string, 0), l...), p))
+		}
+	}
+
+	nodes := newTableau().expand(&tableauNode{-1, map[int]bool{initialNode: true}, formulaSet{phi.String(): phi}, formulaSet{}, formulaSet{}}, make([]*tableauNode, 0))
+
+	// renumbered in order of creation
+	name := make(map[int]nfa.State)
+	name[initialNode] = nfa.State("init")
+	for k, n := range nodes {
+		name[n.name] = nfa.State(fmt.Sprint(k))
+	}
+
+	G := buchi.NewGba()
+	for _, l := range letters {
+		G.Alphabet().Add(Valuation(l...))
+	}
+	G.States().Add(name[initialNode])
+	G.InitialStates().Add(name[initialNode])
+	for _, n := range nodes {
+		G.States().Add(name[n.name])
+	}
+
+	sources := []int{initialNode}
+	for _, m := range nodes {
+		sources = append(sources, m.name)
+	}
+	for _, m := range sources {
+		for _, l := range letters {
+			S := set.NewSet()
+			for _, n := range nodes {
+				if n.incoming[m] == true && n.isSatisfiedBy(l) == true {
+					S.Add(name[n.name])
+				}
+			}
+			G.SetTransition(name[m], Valuation(l...), S)
+		}
+	}
+
+	acceptanceSets := make([]nfa.StateSet, 0)
+	for _, u := range untils(phi) {
+		F := set.NewSet()
+		for _, n := range nodes {
+			if _, ok := n.old[u.String()]; ok == false {
+				F.Add(name[n.name])
+			} else if _, ok := n.old[u.psi.String()]; ok == true {
+				F.Add(name[n.name])
+			}
+		}
+		acceptanceSets = append(acceptanceSets, F)
+	}
+	G.SetAcceptanceSets(acceptanceSets)
+
+	return G
+}
+
+/*****************************************************************************/
+
+// dual of until, only used in negation normal form.
+// phi R psi holds iff psi holds up to and including the first position where phi holds, or forever.
+type releaseFormula struct {
+	phi, psi Formula
+}
+
+func (n releaseFormula) String() string {
+	return fmt.Sprint("((", n.phi, ")R(", n.psi, "))")
+}
+
+func (e releaseFormula) IsEqual(f_ Formula) bool {
+	if f, ok := f_.(releaseFormula); ok == true {
+		return (e.phi.IsEqual(f.phi) && e.psi.IsEqual(f.psi))
+	} // else {
+	return false
+	//}
+}
+
+// negations only in front of atomic propositions.
+// the connectives are and, or, next, until and release.
+func negationNormalForm(phi Formula) Formula {
+	switch f := phi.(type) {
+	case alwaysFormula:
+		return releaseFormula{False(), negationNormalForm(f.phi)}
+	case andFormula:
+		return And(negationNormalForm(f.phi), negationNormalForm(f.psi))
+	case eventuallyFormula:
+		return Until(True(), negationNormalForm(f.phi))
+	case nextFormula:
+		return Next(negationNormalForm(f.phi))
+	case orFormula:
+		return Or(negationNormalForm(f.phi), negationNormalForm(f.psi))
+	case releaseFormula:
+		return releaseFormula{negationNormalForm(f.phi), negationNormalForm(f.psi)}
+	case untilFormula:
+		return Until(negationNormalForm(f.phi), negationNormalForm(f.psi))
+	case notFormula:
+		return negatedNormalForm(f.phi)
+	}
+	return phi // ap, true, false
+}
+
+// negation normal form of ¬phi
+func negatedNormalForm(phi Formula) Formula {
+	switch f := phi.(type) {
+	case alwaysFormula:
+		return Until(True(), negatedNormalForm(f.phi))
+	case andFormula:
+		return Or(negatedNormalForm(f.phi), negatedNormalForm(f.psi))
+	case eventuallyFormula:
+		return releaseFormula{False(), negatedNormalForm(f.phi)}
+	case falseFormula:
+		return True()
+	case nextFormula:
+		return Next(negatedNormalForm(f.phi))
+	case notFormula:
+		return negationNormalForm(f.phi)
+	case orFormula:
+		return And(negatedNormalForm(f.phi), negatedNormalForm(f.psi))
+	case releaseFormula:
+		return Until(negatedNormalForm(f.phi), negatedNormalForm(f.psi))
+	case trueFormula:
+		return False()
+	case untilFormula:
+		return releaseFormula{negatedNormalForm(f.phi), negatedNormalForm(f.psi)}
+	}
+	return Not(phi) // ap
+}
+
+// the immediate subformulas
+func subformulas(phi Formula) []Formula {
+	switch f := phi.(type) {
+	case alwaysFormula:
+		return []Formula{f.phi}
+	case andFormula:
+		return []Formula{f.phi, f.psi}
+	case eventuallyFormula:
+		return []Formula{f.phi}
+	case nextFormula:
+		return []Formula{f.phi}
+	case notFormula:
+		return []Formula{f.phi}
+	case orFormula:
+		return []Formula{f.phi, f.psi}
+	case releaseFormula:
+		return []Formula{f.phi, f.psi}
+	case untilFormula:
+		return []Formula{f.phi, f.psi}
+	}
+	return []Formula{}
+}
+
+// the atomic propositions of phi, sorted
+func aps(phi Formula) []string {
+	seen := make(map[string]bool)
+	var collect func(Formula)
+	collect = func(phi Formula) {
+		if f, ok := phi.(aPFormula); ok == true {
+			seen[f.a] = true
+		}
+		for _, psi := range subformulas(phi) {
+			collect(psi)
+		}
+	}
+	collect(phi)
+
+	ret := make([]string, 0, len(seen))
+	for a := range seen {
+		ret = append(ret, a)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// the distinct until subformulas of phi, outermost first
+func untils(phi Formula) []untilFormula {
+	ret := make([]untilFormula, 0)
+	seen := make(map[string]bool)
+	var collect func(Formula)
+	collect = func(phi Formula) {
+		if f, ok := phi.(untilFormula); ok == true && seen[f.String()] == false {
+			seen[f.String()] = true
+			ret = append(ret, f)
+		}
+		for _, psi := range subformulas(phi) {
+			collect(psi)
+		}
+	}
+	collect(phi)
+	return ret
+}
+
+/*****************************************************************************/
+
+// formulas by their String()
+type formulaSet map[string]Formula
+
+func (S formulaSet) copy() formulaSet {
+	ret := make(formulaSet)
+	for k, phi := range S {
+		ret[k] = phi
+	}
+	return ret
+}
+
+// the keys, sorted
+func (S formulaSet) keys() []string {
+	ret := make([]string, 0, len(S))
+	for k := range S {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (S formulaSet) isEqual(T formulaSet) bool {
+	if len(S) != len(T) {
+		return false
+	}
+	for k := range S {
+		if _, ok := T[k]; ok == false {
+			return false
+		}
+	}
+	return true
+}
+
+// the name of the initial node, which is never expanded
+const initialNode = 0
+
+type tableauNode struct {
+	name     int
+	incoming map[int]bool
+	new      formulaSet // to be processed
+	old      formulaSet // processed, hold now
+	next     formulaSet // hold at the next position
+}
+
+// whether the literals of n hold for the valuation
+func (n tableauNode) isSatisfiedBy(valuation []string) bool {
+	holds := make(map[string]bool)
+	for _, a := range valuation {
+		holds[a] = true
+	}
+	for _, phi := range n.old {
+		switch f := phi.(type) {
+		case aPFormula:
+			if holds[f.a] == false {
+				return false
+			}
+		case notFormula:
+			if holds[f.phi.(aPFormula).a] == true {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type tableau struct {
+	names int
+}
+
+func newTableau() *tableau {
+	return &tableau{initialNode}
+}
+
+func (t *tableau) newName() int {
+	t.names += 1
+	return t.names
+}
+
+// expands n and adds the resulting nodes to nodes
+func (t *tableau) expand(n *tableauNode, nodes []*tableauNode) []*tableauNode {
+	if len(n.new) == 0 {
+		for _, m := range nodes {
+			if m.old.isEqual(n.old) == true && m.next.isEqual(n.next) == true {
+				for k := range n.incoming {
+					m.incoming[k] = true
+				}
+				return nodes
+			}
+		}
+
+		n.name = t.newName()
+		nodes = append(nodes, n)
+		return t.expand(&tableauNode{-1, map[int]bool{n.name: true}, n.next.copy(), formulaSet{}, formulaSet{}}, nodes)
+	}
+
+	// the smallest formula, so that the result is deterministic
+	k := n.new.keys()[0]
+	eta := n.new[k]
+	delete(n.new, k)
+
+	if _, ok := n.old[k]; ok == true {
+		return t.expand(n, nodes)
+	}
+
+	// adds the formulas to new unless they were processed already
+	add := func(S formulaSet, phis ...Formula) formulaSet {
+		for _, phi := range phis {
+			if _, ok := n.old[phi.String()]; ok == false {
+				S[phi.String()] = phi
+			}
+		}
+		return S
+	}
+
+	split := func(new1 []Formula, next1 []Formula, new2 []Formula) []*tableauNode {
+		old := n.old.copy()
+		old[k] = eta
+
+		next := n.next.copy()
+		for _, phi := range next1 {
+			next[phi.String()] = phi
+		}
+		n1 := &tableauNode{-1, copyIncoming(n.incoming), add(n.new.copy(), new1...), old, next}
+		n2 := &tableauNode{-1, copyIncoming(n.incoming), add(n.new.copy(), new2...), old.copy(), n.next.copy()}
+
+		return t.expand(n2, t.expand(n1, nodes))
+	}
+
+	switch f := eta.(type) {
+	case falseFormula:
+		return nodes
+	case trueFormula, aPFormula, notFormula:
+		if _, ok := n.old[negatedNormalForm(eta).String()]; ok == true {
+			return nodes // contradiction
+		}
+		n.old[k] = eta
+		return t.expand(n, nodes)
+	case andFormula:
+		n.new = add(n.new, f.phi, f.psi)
+		n.old[k] = eta
+		return t.expand(n, nodes)
+	case nextFormula:
+		n.old[k] = eta
+		n.next[f.phi.String()] = f.phi
+		return t.expand(n, nodes)
+	case orFormula:
+		return split([]Formula{f.phi}, nil, []Formula{f.psi})
+	case untilFormula:
+		return split([]Formula{f.phi}, []Formula{eta}, []Formula{f.psi})
+	case releaseFormula:
+		return split([]Formula{f.psi}, []Formula{eta}, []Formula{f.phi, f.psi})
+	}
+
+	panic(fmt.Sprint("unexpected formula ", eta, " in negation normal form"))
+}
+
+func copyIncoming(incoming map[int]bool) map[int]bool {
+	ret := make(map[int]bool)
+	for k := range incoming {
+		ret[k] = true
+	}
+	return ret
+}
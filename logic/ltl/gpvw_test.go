@@ -0,0 +1,264 @@
+package ltl
+
+import (
+	"github.com/hydroo/gomochex/automaton/buchi"
+	"github.com/hydroo/gomochex/automaton/nfa"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestValuation(t *testing.T) {
+	if Valuation() != "{}" || Valuation("b", "a") != "{a,b}" || Valuation("π") != "{π}" {
+		t.Error(Valuation(), Valuation("b", "a"))
+	}
+}
+
+func TestNegationNormalForm(t *testing.T) {
+	type test struct {
+		phi, expected string
+	}
+
+	tests := []test{
+		test{"¬(□(a))", "((true)U(¬(a)))"},
+		test{"¬(◇(a))", "((false)R(¬(a)))"},
+		test{"¬(((a)U(b)))", "((¬(a))R(¬(b)))"},
+		test{"¬((a∧○(¬(b))))", "(¬(a)∨○(b))"},
+		test{"¬(¬((true∨false)))", "(true∨false)"},
+	}
+
+	for k, x := range tests {
+		phi, _ := FormulaFromString(x.phi)
+		if s := negationNormalForm(phi).String(); s != x.expected {
+			t.Error("case", k, s)
+		}
+	}
+}
+
+func TestToGBA(t *testing.T) {
+	a, b, none, both := Valuation("a"), Valuation("b"), Valuation(), Valuation("a", "b")
+
+	type test struct {
+		phi          string
+		prefix, loop []nfa.Letter
+		accepted     bool
+	}
+
+	tests := []test{
+		test{"□(◇(a))", nil, []nfa.Letter{a, b}, true},
+		test{"□(◇(a))", []nfa.Letter{a}, []nfa.Letter{b}, false},
+		test{"◇(□(a))", []nfa.Letter{b, none}, []nfa.Letter{both}, true},
+		test{"◇(□(a))", nil, []nfa.Letter{a, b}, false},
+		test{"((a)U(b))", []nfa.Letter{a, a}, []nfa.Letter{b}, true},
+		test{"((a)U(b))", []nfa.Letter{a, none}, []nfa.Letter{b}, false},
+		test{"((a)U(b))", nil, []nfa.Letter{a}, false},
+		test{"○(¬(a))", []nfa.Letter{a, b}, []nfa.Letter{a}, true},
+		test{"○(¬(a))", []nfa.Letter{b, a}, []nfa.Letter{b}, false},
+		test{"false", nil, []nfa.Letter{none}, false},
+		test{"true", nil, []nfa.Letter{none}, true},
+		test{"(□((a∨b))∧□(◇(¬(a))))", nil, []nfa.Letter{a, b}, true},
+		test{"(□((a∨b))∧□(◇(¬(a))))", nil, []nfa.Letter{a, none}, false},
+	}
+
+	for k, x := range tests {
+		phi, ok := FormulaFromString(x.phi)
+		if ok == false {
+			t.Fatal("case", k)
+		}
+		G := ToGBA(phi)
+		if G.Validate() != nil {
+			t.Error("case", k, G.Validate())
+		}
+		// the letters of a and b when the formula does not mention one of them
+		prefix, loop := project(x.prefix, aps(phi)), project(x.loop, aps(phi))
+		if G.AcceptsLasso(prefix, loop) != x.accepted {
+			t.Error("case", k, G)
+		}
+		if buchi.Degeneralize(G).AcceptsLasso(prefix, loop) != x.accepted {
+			t.Error("case", k, buchi.Degeneralize(G))
+		}
+	}
+
+	// one acceptance set per until
+	phi, _ := FormulaFromString("(□(◇(a))∧◇(((a)U(b))))")
+	if G := ToGBA(phi); len(G.AcceptanceSets()) != 3 || G.Alphabet().Size() != 4 {
+		t.Error(G)
+	}
+}
+
+func TestToGBAAgainstBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	props := []string{"a", "b"}
+
+	randomWord := func(n int) [][]string {
+		ret := make([][]string, n)
+		for i := range ret {
+			ret[i] = make([]string, 0)
+			for _, p := range props {
+				if rng.Intn(2) == 0 {
+					ret[i] = append(ret[i], p)
+				}
+			}
+		}
+		return ret
+	}
+
+	accepted, rejected := 0, 0
+	for k := 0; k < 300; k += 1 {
+		phi := randomFormula(rng, props, 4)
+		G := ToGBA(phi)
+		B := buchi.Degeneralize(G)
+
+		for l := 0; l < 20; l += 1 {
+			prefix, loop := randomWord(rng.Intn(4)), randomWord(1+rng.Intn(4))
+			expected := holds(phi, prefix, loop)
+			if expected == true {
+				accepted += 1
+			} else {
+				rejected += 1
+			}
+
+			u, v := project(letters(prefix), aps(phi)), project(letters(loop), aps(phi))
+			if G.AcceptsLasso(u, v) != expected || B.AcceptsLasso(u, v) != expected {
+				t.Error("case", k, phi, prefix, loop, expected)
+			}
+		}
+	}
+
+	if accepted < 1000 || rejected < 1000 {
+		t.Error(accepted, rejected)
+	}
+}
+
+func randomFormula(rng *rand.Rand, props []string, depth int) Formula {
+	if depth == 0 || rng.Intn(4) == 0 {
+		switch rng.Intn(8) {
+		case 0:
+			return True()
+		case 1:
+			return False()
+		}
+		return Ap(props[rng.Intn(len(props))])
+	}
+
+	phi := randomFormula(rng, props, depth-1)
+	switch rng.Intn(8) {
+	case 0:
+		return Always(phi)
+	case 1:
+		return Eventually(phi)
+	case 2:
+		return Next(phi)
+	case 3:
+		return Not(phi)
+	case 4:
+		return And(phi, randomFormula(rng, props, depth-1))
+	case 5:
+		return Or(phi, randomFormula(rng, props, depth-1))
+	}
+	return Until(phi, randomFormula(rng, props, depth-1))
+}
+
+// whether prefix.loop.loop... satisfies phi, by evaluating every subformula at every position
+func holds(phi Formula, prefix, loop [][]string) bool {
+	word := append(append(make([][]string, 0), prefix...), loop...)
+	n := len(word)
+	next := func(i int) int {
+		if i+1 == n {
+			return len(prefix)
+		}
+		return i + 1
+	}
+
+	// least or greatest fixpoint of sat[i] = now[i] || (stay[i] && sat[next(i)])
+	fixpoint := func(now, stay []bool, greatest bool) []bool {
+		sat := make([]bool, n)
+		for i := range sat {
+			sat[i] = greatest
+		}
+		for round := 0; round <= n; round += 1 {
+			for i := n - 1; i >= 0; i -= 1 {
+				sat[i] = now[i] || (stay[i] && sat[next(i)])
+			}
+		}
+		return sat
+	}
+
+	constant := func(b bool) []bool {
+		ret := make([]bool, n)
+		for i := range ret {
+			ret[i] = b
+		}
+		return ret
+	}
+
+	var evaluate func(Formula) []bool
+	evaluate = func(phi Formula) []bool {
+		ret := make([]bool, n)
+		switch f := phi.(type) {
+		case aPFormula:
+			for i, l := range word {
+				for _, a := range l {
+					if a == f.a {
+						ret[i] = true
+					}
+				}
+			}
+		case trueFormula:
+			ret = constant(true)
+		case notFormula:
+			for i, x := range evaluate(f.phi) {
+				ret[i] = !x
+			}
+		case andFormula:
+			x, y := evaluate(f.phi), evaluate(f.psi)
+			for i := range ret {
+				ret[i] = x[i] && y[i]
+			}
+		case orFormula:
+			x, y := evaluate(f.phi), evaluate(f.psi)
+			for i := range ret {
+				ret[i] = x[i] || y[i]
+			}
+		case nextFormula:
+			x := evaluate(f.phi)
+			for i := range ret {
+				ret[i] = x[next(i)]
+			}
+		case untilFormula:
+			ret = fixpoint(evaluate(f.psi), evaluate(f.phi), false)
+		case eventuallyFormula:
+			ret = fixpoint(evaluate(f.phi), constant(true), false)
+		case alwaysFormula:
+			ret = fixpoint(constant(false), evaluate(f.phi), true)
+		}
+		return ret
+	}
+
+	return evaluate(phi)[0]
+}
+
+func letters(word [][]string) []nfa.Letter {
+	ret := make([]nfa.Letter, len(word))
+	for i, l := range word {
+		ret[i] = Valuation(l...)
+	}
+	return ret
+}
+
+// the letters restricted to the given propositions
+func project(word []nfa.Letter, props []string) []nfa.Letter {
+	ret := make([]nfa.Letter, len(word))
+	for i, l := range word {
+		kept := make([]string, 0)
+		for _, a := range strings.Split(strings.Trim(string(l), "{}"), ",") {
+			for _, p := range props {
+				if a == p {
+					kept = append(kept, p)
+				}
+			}
+		}
+		ret[i] = Valuation(kept...)
+	}
+	return ret
+}
@@ -3,7 +3,6 @@ package regex
 import (
 	"fmt"
 	"github.com/hydroo/gomochex/automaton/nfa"
-	"github.com/hydroo/gomochex/basic/set"
 	"strings"
 	"unicode/utf8"
 )
@@ -3,7 +3,6 @@ package regex
 import (
 	//"fmt"
 	"github.com/hydroo/gomochex/automaton/nfa"
-	"github.com/hydroo/gomochex/basic/set"
 	"testing"
 )
 
@@ -54,3 +53,42 @@ func TestExpressionFromString(t *testing.T) {
 		t.Error()
 	}
 }
+
+func TestNfa(t *testing.T) {
+	e, _ := ExpressionFromString("((a.b)+(c)*)")
+	A := e.Nfa()
+
+	for _, w := range [][]nfa.Letter{{"a", "b"}, {}, {"c"}, {"c", "c", "c"}} {
+		if A.Accepts(w) != true {
+			t.Error(w)
+		}
+	}
+
+	for _, w := range [][]nfa.Letter{{"a"}, {"b"}, {"a", "b", "c"}, {"c", "a", "b"}} {
+		if A.Accepts(w) != false {
+			t.Error(w)
+		}
+	}
+}
+
+func TestNfaEquivalent(t *testing.T) {
+	type test struct {
+		e, f          string
+		shouldBeEqual bool
+	}
+
+	tests := []test{
+		test{"((a.b)+(a.c))", "(a.(c+b))", true},
+		test{"((a)*.(a)*)", "(a)*", true},
+		test{"(a.((b.a))*)", "(((a.b))*.a)", true},
+		test{"((a+b))*", "((a)*+(b)*)", false},
+	}
+
+	for k, x := range tests {
+		e, _ := ExpressionFromString(x.e)
+		f, _ := ExpressionFromString(x.f)
+		if equal, counterexample := nfa.Equivalent(e.Nfa(), f.Nfa()); equal != x.shouldBeEqual {
+			t.Error("case ", k, counterexample)
+		}
+	}
+}